@@ -0,0 +1,407 @@
+package cfg
+
+import (
+	"fmt"
+
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+)
+
+// builder creates a graph by walking statements of a function body.
+type builder struct {
+	g   *Graph
+	cur *Block
+	// targets is a stack of blocks for break, continue and fallthrough statements
+	targets *targets
+	// labels maps label names to blocks they start; blocks are created on the first goto or declaration
+	labels map[string]*Block
+	// defers is a list of deferred calls in the order of appearance
+	defers []Node
+}
+
+// targets is a set of blocks that are targets of branch statements in the current statement.
+type targets struct {
+	tail *targets
+	// label is the name of the label of the statement, if any
+	label                string
+	brk, cont, fallthrgh *Block
+}
+
+func (b *builder) newBlock(kind string) *Block {
+	bl := &Block{Index: len(b.g.Blocks), Kind: kind, Nodes: []Node{}, Succs: []Edge{}}
+	b.g.Blocks = append(b.g.Blocks, bl)
+	return bl
+}
+
+// add appends a node to the current block. Nil nodes are ignored.
+func (b *builder) add(n nodes.Node) {
+	if obj, ok := n.(nodes.Object); ok {
+		b.cur.Nodes = append(b.cur.Nodes, refOf(obj))
+	}
+}
+
+// jump adds an edge from the current block.
+func (b *builder) jump(to *Block, kind string) {
+	b.cur.Succs = append(b.cur.Succs, Edge{Target: to.Index, Kind: kind})
+}
+
+// terminate ends the current block after an unconditional transfer of control.
+// The following statements, if any, are placed to an unreachable block.
+func (b *builder) terminate() {
+	b.cur = b.newBlock(KindUnreachable)
+}
+
+// label returns a block started by a given label.
+func (b *builder) label(name string) *Block {
+	bl := b.labels[name]
+	if bl == nil {
+		bl = b.newBlock(KindLabel)
+		bl.Label = name
+		b.labels[name] = bl
+	}
+	return bl
+}
+
+func (b *builder) push(t targets) {
+	t.tail = b.targets
+	b.targets = &t
+}
+
+func (b *builder) pop() {
+	b.targets = b.targets.tail
+}
+
+func (b *builder) stmts(list nodes.Node) error {
+	arr, _ := list.(nodes.Array)
+	for _, s := range arr {
+		if err := b.stmt(s, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stmt adds a statement to the graph. The label argument is set for labeled statements.
+func (b *builder) stmt(n nodes.Node, label string) error {
+	s, ok := n.(nodes.Object)
+	if !ok {
+		return nil
+	}
+	switch uast.TypeOf(s) {
+	case "BlockStmt":
+		return b.stmts(s["List"])
+	case "LabeledStmt":
+		name := identName(s["Label"])
+		bl := b.label(name)
+		b.jump(bl, EdgeNext)
+		b.cur = bl
+		return b.stmt(s["Stmt"], name)
+	case "ReturnStmt":
+		b.add(s)
+		b.jump(b.g.Exit(), EdgeReturn)
+		b.terminate()
+	case "BranchStmt":
+		return b.branch(s)
+	case "ExprStmt":
+		b.add(s)
+		if isPanic(s["X"]) {
+			b.jump(b.g.Exit(), EdgePanic)
+			b.terminate()
+		}
+	case "DeferStmt":
+		b.add(s)
+		if call, ok := s["Call"].(nodes.Object); ok {
+			b.defers = append(b.defers, refOf(call))
+		}
+	case "IfStmt":
+		return b.ifStmt(s)
+	case "ForStmt":
+		return b.forStmt(s, label)
+	case "RangeStmt":
+		return b.rangeStmt(s, label)
+	case "SwitchStmt":
+		if err := b.stmt(s["Init"], ""); err != nil {
+			return err
+		}
+		b.add(s["Tag"])
+		return b.switchBody(s["Body"], label)
+	case "TypeSwitchStmt":
+		if err := b.stmt(s["Init"], ""); err != nil {
+			return err
+		}
+		b.add(s["Assign"])
+		return b.switchBody(s["Body"], label)
+	case "SelectStmt":
+		return b.selectStmt(s, label)
+	default:
+		// simple statements: assignments, declarations, go statements, etc.
+		b.add(s)
+	}
+	return nil
+}
+
+func (b *builder) branch(s nodes.Object) error {
+	tok, _ := s["Tok"].(nodes.String)
+	label := identName(s["Label"])
+	var (
+		target *Block
+		kind   string
+	)
+	switch tok {
+	case "break":
+		kind = EdgeBreak
+		for t := b.targets; t != nil && target == nil; t = t.tail {
+			if label == "" || t.label == label {
+				target = t.brk
+			}
+		}
+	case "continue":
+		kind = EdgeContinue
+		for t := b.targets; t != nil && target == nil; t = t.tail {
+			if label == "" || t.label == label {
+				target = t.cont
+			}
+		}
+	case "fallthrough":
+		kind = EdgeFallthrough
+		if b.targets != nil {
+			target = b.targets.fallthrgh
+		}
+	case "goto":
+		kind = EdgeGoto
+		target = b.label(label)
+	}
+	if target == nil {
+		return fmt.Errorf("cfg: no target for %s %s at %v", tok, label, refOf(s).Start)
+	}
+	b.add(s)
+	b.jump(target, kind)
+	b.terminate()
+	return nil
+}
+
+func (b *builder) ifStmt(s nodes.Object) error {
+	if err := b.stmt(s["Init"], ""); err != nil {
+		return err
+	}
+	then := b.newBlock(KindIfThen)
+	done := b.newBlock(KindIfDone)
+	els := done
+	if s["Else"] != nil {
+		els = b.newBlock(KindIfElse)
+	}
+	b.add(s["Cond"])
+	b.jump(then, EdgeTrue)
+	b.jump(els, EdgeFalse)
+
+	b.cur = then
+	if err := b.stmt(s["Body"], ""); err != nil {
+		return err
+	}
+	b.jump(done, EdgeNext)
+
+	if s["Else"] != nil {
+		b.cur = els
+		if err := b.stmt(s["Else"], ""); err != nil {
+			return err
+		}
+		b.jump(done, EdgeNext)
+	}
+	b.cur = done
+	return nil
+}
+
+func (b *builder) forStmt(s nodes.Object, label string) error {
+	if err := b.stmt(s["Init"], ""); err != nil {
+		return err
+	}
+	loop := b.newBlock(KindForLoop)
+	body := b.newBlock(KindForBody)
+	done := b.newBlock(KindForDone)
+	cont := loop
+	if s["Post"] != nil {
+		cont = b.newBlock(KindForPost)
+	}
+	b.jump(loop, EdgeNext)
+
+	b.cur = loop
+	if s["Cond"] != nil {
+		b.add(s["Cond"])
+		b.jump(body, EdgeTrue)
+		b.jump(done, EdgeFalse)
+	} else {
+		b.jump(body, EdgeNext)
+	}
+
+	b.push(targets{label: label, brk: done, cont: cont})
+	b.cur = body
+	if err := b.stmt(s["Body"], ""); err != nil {
+		return err
+	}
+	b.jump(cont, EdgeNext)
+	b.pop()
+
+	if s["Post"] != nil {
+		b.cur = cont
+		if err := b.stmt(s["Post"], ""); err != nil {
+			return err
+		}
+		b.jump(loop, EdgeNext)
+	}
+	b.cur = done
+	return nil
+}
+
+func (b *builder) rangeStmt(s nodes.Object, label string) error {
+	// the range expression is evaluated once before the loop
+	b.add(s["X"])
+	loop := b.newBlock(KindRangeLoop)
+	body := b.newBlock(KindRangeBody)
+	done := b.newBlock(KindRangeDone)
+	b.jump(loop, EdgeNext)
+
+	// the loop block assigns the next key and value
+	b.cur = loop
+	b.add(s["Key"])
+	b.add(s["Value"])
+	b.jump(body, EdgeTrue)
+	b.jump(done, EdgeFalse)
+
+	b.push(targets{label: label, brk: done, cont: loop})
+	b.cur = body
+	if err := b.stmt(s["Body"], ""); err != nil {
+		return err
+	}
+	b.jump(loop, EdgeNext)
+	b.pop()
+
+	b.cur = done
+	return nil
+}
+
+// switchBody adds clauses of an expression or a type switch. Case expressions are evaluated
+// one after another, and the default clause is taken if none of them matches.
+func (b *builder) switchBody(body nodes.Node, label string) error {
+	blk, _ := body.(nodes.Object)
+	clauses, _ := blk["List"].(nodes.Array)
+	done := b.newBlock(KindSwitchDone)
+	bodies := make([]*Block, len(clauses))
+	for i := range clauses {
+		bodies[i] = b.newBlock(KindSwitchCase)
+	}
+	var dflt *Block
+	for i, c := range clauses {
+		c, _ := c.(nodes.Object)
+		list, _ := c["List"].(nodes.Array)
+		if len(list) == 0 {
+			dflt = bodies[i]
+			continue
+		}
+		for _, e := range list {
+			b.add(e)
+		}
+		next := b.newBlock(KindSwitchNext)
+		b.jump(bodies[i], EdgeTrue)
+		b.jump(next, EdgeFalse)
+		b.cur = next
+	}
+	if dflt != nil {
+		b.jump(dflt, EdgeNext)
+	} else {
+		b.jump(done, EdgeNext)
+	}
+	for i, c := range clauses {
+		c, _ := c.(nodes.Object)
+		t := targets{label: label, brk: done}
+		if i+1 < len(bodies) {
+			t.fallthrgh = bodies[i+1]
+		}
+		b.push(t)
+		b.cur = bodies[i]
+		if err := b.stmts(c["Body"]); err != nil {
+			return err
+		}
+		b.jump(done, EdgeNext)
+		b.pop()
+	}
+	b.cur = done
+	return nil
+}
+
+// selectStmt adds clauses of a select statement. Each clause starts with its communication.
+// A select without clauses blocks forever.
+func (b *builder) selectStmt(s nodes.Object, label string) error {
+	blk, _ := s["Body"].(nodes.Object)
+	clauses, _ := blk["List"].(nodes.Array)
+	start := b.cur
+	done := b.newBlock(KindSelectDone)
+	b.push(targets{label: label, brk: done})
+	for _, c := range clauses {
+		c, _ := c.(nodes.Object)
+		bl := b.newBlock(KindSelectCase)
+		b.cur = start
+		b.jump(bl, EdgeCase)
+		b.cur = bl
+		if err := b.stmt(c["Comm"], ""); err != nil {
+			return err
+		}
+		if err := b.stmts(c["Body"]); err != nil {
+			return err
+		}
+		b.jump(done, EdgeNext)
+	}
+	b.pop()
+	b.cur = done
+	return nil
+}
+
+// deferCalls adds blocks for deferred calls. Since deferred calls are executed in the reverse
+// order when the function returns or panics, all edges to the exit block are redirected
+// to the last deferred call, and each of them leads to the previous one.
+//
+// The graph is conservative: all deferred calls are considered registered on every path.
+func (b *builder) deferCalls() {
+	if len(b.defers) == 0 {
+		return
+	}
+	exit := b.g.Exit()
+	blocks := make([]*Block, len(b.defers))
+	for i := range blocks {
+		blocks[i] = b.newBlock(KindDefer)
+		blocks[i].Nodes = append(blocks[i].Nodes, b.defers[len(b.defers)-1-i])
+	}
+	for _, bl := range b.g.Blocks {
+		for i, e := range bl.Succs {
+			if e.Target == exit.Index {
+				bl.Succs[i].Target = blocks[0].Index
+			}
+		}
+	}
+	for i, bl := range blocks {
+		next := exit
+		if i+1 < len(blocks) {
+			next = blocks[i+1]
+		}
+		b.cur = bl
+		b.jump(next, EdgeDefer)
+	}
+}
+
+// isPanic checks if an expression is a call of the builtin panic function.
+func isPanic(n nodes.Node) bool {
+	call, ok := n.(nodes.Object)
+	if !ok || uast.TypeOf(call) != "CallExpr" {
+		return false
+	}
+	return identName(call["Fun"]) == "panic"
+}
+
+// identName returns the name of a native identifier, or an empty string if the node is not an identifier.
+func identName(n nodes.Node) string {
+	id, ok := n.(nodes.Object)
+	if !ok || uast.TypeOf(id) != "Ident" {
+		return ""
+	}
+	name, _ := id["Name"].(nodes.String)
+	return string(name)
+}
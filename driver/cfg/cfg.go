@@ -0,0 +1,243 @@
+// Package cfg builds intra-procedural control-flow graphs of Go functions from the native AST
+// returned by golang.Parse.
+//
+// Each function declaration and function literal gets its own graph. A graph consists of basic
+// blocks that refer to statements and expressions of the function by their type and positions,
+// and of edges between the blocks labeled with the kind of the transfer of control.
+// Graphs can be serialized as JSON or converted to UAST nodes with Graph.ToNode.
+package cfg
+
+import (
+	"fmt"
+
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+)
+
+// Kinds of basic blocks.
+const (
+	KindEntry       = "entry"
+	KindExit        = "exit"
+	KindIfThen      = "if.then"
+	KindIfElse      = "if.else"
+	KindIfDone      = "if.done"
+	KindForLoop     = "for.loop"
+	KindForBody     = "for.body"
+	KindForPost     = "for.post"
+	KindForDone     = "for.done"
+	KindRangeLoop   = "range.loop"
+	KindRangeBody   = "range.body"
+	KindRangeDone   = "range.done"
+	KindSwitchNext  = "switch.next"
+	KindSwitchCase  = "switch.case"
+	KindSwitchDone  = "switch.done"
+	KindSelectCase  = "select.case"
+	KindSelectDone  = "select.done"
+	KindLabel       = "label"
+	KindDefer       = "defer"
+	KindUnreachable = "unreachable"
+)
+
+// Kinds of edges.
+const (
+	EdgeNext        = "next"
+	EdgeTrue        = "true"
+	EdgeFalse       = "false"
+	EdgeCase        = "case"
+	EdgeBreak       = "break"
+	EdgeContinue    = "continue"
+	EdgeGoto        = "goto"
+	EdgeFallthrough = "fallthrough"
+	EdgeReturn      = "return"
+	EdgePanic       = "panic"
+	EdgeDefer       = "defer"
+)
+
+// Graph is a control-flow graph of a single function.
+type Graph struct {
+	// Type is the type of the function node: FuncDecl or FuncLit.
+	Type string `json:"type"`
+	// Name is the name of a declared function. It is empty for function literals.
+	Name  string        `json:"name,omitempty"`
+	Start uast.Position `json:"start"`
+	End   uast.Position `json:"end"`
+	// Blocks is a list of basic blocks of the function. The first block is the entry
+	// block and the second one is the exit block.
+	Blocks []*Block `json:"blocks"`
+}
+
+// Entry returns the entry block of the function.
+func (g *Graph) Entry() *Block { return g.Blocks[0] }
+
+// Exit returns the exit block of the function. All returns and panics lead to it,
+// possibly through the blocks of deferred calls.
+func (g *Graph) Exit() *Block { return g.Blocks[1] }
+
+// Block is a basic block: a sequence of nodes that are executed one after another.
+type Block struct {
+	Index int    `json:"index"`
+	Kind  string `json:"kind"`
+	// Label is the name of a label that starts the block, if any.
+	Label string `json:"label,omitempty"`
+	// Nodes is a list of statements and expressions of the block in the order of execution.
+	Nodes []Node `json:"nodes"`
+	Succs []Edge `json:"succs"`
+	// Live is set if the block is reachable from the entry block.
+	Live bool `json:"live"`
+}
+
+// Edge is a transfer of control to another block.
+type Edge struct {
+	Target int    `json:"target"`
+	Kind   string `json:"kind"`
+}
+
+// Node is a reference to a native AST node of the function.
+type Node struct {
+	Type  string        `json:"type"`
+	Start uast.Position `json:"start"`
+	End   uast.Position `json:"end"`
+}
+
+// Build creates control-flow graphs for all function declarations and function literals
+// in a native AST, in the order of their appearance.
+func Build(root nodes.Node) ([]*Graph, error) {
+	var (
+		out  []*Graph
+		last error
+	)
+	nodes.WalkPreOrder(root, func(n nodes.Node) bool {
+		obj, ok := n.(nodes.Object)
+		if !ok || last != nil {
+			return last == nil
+		}
+		switch uast.TypeOf(obj) {
+		case "FuncDecl", "FuncLit":
+			g, err := New(obj)
+			if err != nil {
+				last = err
+				return false
+			}
+			out = append(out, g)
+		}
+		return true
+	})
+	return out, last
+}
+
+// New creates a control-flow graph for a native FuncDecl or FuncLit node.
+// Function literals in the body are not a part of the graph, except for the expression itself.
+func New(fnc nodes.Object) (*Graph, error) {
+	typ := uast.TypeOf(fnc)
+	if typ != "FuncDecl" && typ != "FuncLit" {
+		return nil, fmt.Errorf("cfg: expected a function, got %q", typ)
+	}
+	ref := refOf(fnc)
+	g := &Graph{Type: typ, Start: ref.Start, End: ref.End}
+	if name, ok := fnc["Name"].(nodes.Object); ok {
+		s, _ := name["Name"].(nodes.String)
+		g.Name = string(s)
+	}
+	b := &builder{g: g, labels: make(map[string]*Block)}
+	b.cur = b.newBlock(KindEntry)
+	exit := b.newBlock(KindExit)
+	if body, ok := fnc["Body"].(nodes.Object); ok {
+		if err := b.stmt(body, ""); err != nil {
+			return nil, err
+		}
+	}
+	// falling off the end of the function is an implicit return
+	b.jump(exit, EdgeNext)
+	b.deferCalls()
+	g.markLive()
+	return g, nil
+}
+
+// markLive marks all blocks reachable from the entry.
+func (g *Graph) markLive() {
+	queue := []*Block{g.Entry()}
+	g.Entry().Live = true
+	for len(queue) != 0 {
+		bl := queue[0]
+		queue = queue[1:]
+		for _, e := range bl.Succs {
+			if next := g.Blocks[e.Target]; !next.Live {
+				next.Live = true
+				queue = append(queue, next)
+			}
+		}
+	}
+}
+
+// refOf returns a reference to a native node.
+func refOf(n nodes.Object) Node {
+	ref := Node{Type: uast.TypeOf(n)}
+	ps := uast.PositionsOf(n)
+	if p := ps.Start(); p != nil {
+		ref.Start = *p
+	}
+	if p := ps.End(); p != nil {
+		ref.End = *p
+	}
+	return ref
+}
+
+// NS is a namespace of UAST node types produced by Graph.ToNode.
+const NS = "cfg"
+
+// ToNode converts the graph to a UAST subtree that can be attached to the function node.
+// Graph and node references keep positions of the original nodes.
+func (g *Graph) ToNode() nodes.Object {
+	blocks := make(nodes.Array, 0, len(g.Blocks))
+	for _, bl := range g.Blocks {
+		blocks = append(blocks, bl.toNode())
+	}
+	obj := nodes.Object{
+		uast.KeyType: nodes.String(NS + ":Graph"),
+		uast.KeyPos:  positions(g.Start, g.End),
+		"Type":       nodes.String(g.Type),
+		"Blocks":     blocks,
+	}
+	if g.Name != "" {
+		obj["Name"] = nodes.String(g.Name)
+	}
+	return obj
+}
+
+func (bl *Block) toNode() nodes.Object {
+	refs := make(nodes.Array, 0, len(bl.Nodes))
+	for _, n := range bl.Nodes {
+		refs = append(refs, nodes.Object{
+			uast.KeyType: nodes.String(NS + ":Node"),
+			uast.KeyPos:  positions(n.Start, n.End),
+			"Type":       nodes.String(n.Type),
+		})
+	}
+	succs := make(nodes.Array, 0, len(bl.Succs))
+	for _, e := range bl.Succs {
+		succs = append(succs, nodes.Object{
+			uast.KeyType: nodes.String(NS + ":Edge"),
+			"Target":     nodes.Int(e.Target),
+			"Kind":       nodes.String(e.Kind),
+		})
+	}
+	obj := nodes.Object{
+		uast.KeyType: nodes.String(NS + ":Block"),
+		"Index":      nodes.Int(bl.Index),
+		"Kind":       nodes.String(bl.Kind),
+		"Nodes":      refs,
+		"Succs":      succs,
+		"Live":       nodes.Bool(bl.Live),
+	}
+	if bl.Label != "" {
+		obj["Label"] = nodes.String(bl.Label)
+	}
+	return obj
+}
+
+func positions(start, end uast.Position) nodes.Object {
+	return uast.Positions{
+		uast.KeyStart: start,
+		uast.KeyEnd:   end,
+	}.ToObject()
+}
@@ -0,0 +1,190 @@
+package cfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/bblfsh/go-driver/driver/golang"
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/stretchr/testify/require"
+)
+
+// buildOne builds a graph for the only function in the source.
+func buildOne(t testing.TB, code string) *Graph {
+	n, err := golang.Parse(code)
+	require.NoError(t, err)
+	graphs, err := Build(n)
+	require.NoError(t, err)
+	require.Len(t, graphs, 1)
+	return graphs[0]
+}
+
+// edges lists edges of live blocks as "from(kind) -kind-> to(kind)" strings.
+func edges(g *Graph) []string {
+	var out []string
+	for _, bl := range g.Blocks {
+		if !bl.Live {
+			continue
+		}
+		for _, e := range bl.Succs {
+			to := g.Blocks[e.Target]
+			out = append(out, fmt.Sprintf("%d(%s) -%s-> %d(%s)", bl.Index, bl.Kind, e.Kind, to.Index, to.Kind))
+		}
+	}
+	return out
+}
+
+func TestIfElse(t *testing.T) {
+	g := buildOne(t, `package p
+func f(x int) int {
+	if x > 0 {
+		return 1
+	} else {
+		x++
+	}
+	return x
+}`)
+	require.Equal(t, "f", g.Name)
+	require.Equal(t, "FuncDecl", g.Type)
+	require.Equal(t, []string{
+		"0(entry) -true-> 2(if.then)",
+		"0(entry) -false-> 4(if.else)",
+		"2(if.then) -return-> 1(exit)",
+		"3(if.done) -return-> 1(exit)",
+		"4(if.else) -next-> 3(if.done)",
+	}, edges(g))
+	require.Equal(t, []Node{{
+		Type:  "BinaryExpr",
+		Start: uast.Position{Offset: 34, Line: 3, Col: 5},
+		End:   uast.Position{Offset: 39, Line: 3, Col: 10},
+	}}, g.Entry().Nodes)
+	// the statement after return is in an unreachable block
+	require.Equal(t, KindUnreachable, g.Blocks[5].Kind)
+	require.False(t, g.Blocks[5].Live)
+}
+
+func TestLoops(t *testing.T) {
+	g := buildOne(t, `package p
+func f(m map[int]int) {
+outer:
+	for i := 0; i < 10; i++ {
+		for k := range m {
+			if k == i {
+				continue outer
+			}
+			break
+		}
+	}
+}`)
+	require.Equal(t, []string{
+		"0(entry) -next-> 2(label)",
+		"2(label) -next-> 3(for.loop)",
+		"3(for.loop) -true-> 4(for.body)",
+		"3(for.loop) -false-> 5(for.done)",
+		"4(for.body) -next-> 7(range.loop)",
+		"5(for.done) -next-> 1(exit)",
+		"6(for.post) -next-> 3(for.loop)",
+		"7(range.loop) -true-> 8(range.body)",
+		"7(range.loop) -false-> 9(range.done)",
+		"8(range.body) -true-> 10(if.then)",
+		"8(range.body) -false-> 11(if.done)",
+		"9(range.done) -next-> 6(for.post)",
+		"10(if.then) -continue-> 6(for.post)",
+		"11(if.done) -break-> 9(range.done)",
+	}, edges(g))
+	require.Equal(t, "outer", g.Blocks[2].Label)
+}
+
+func TestSwitch(t *testing.T) {
+	g := buildOne(t, `package p
+func f(x int) {
+	switch x {
+	case 1, 2:
+		fallthrough
+	case 3:
+		x++
+	default:
+		goto end
+	}
+end:
+}`)
+	require.Equal(t, []string{
+		"0(entry) -true-> 3(switch.case)",
+		"0(entry) -false-> 6(switch.next)",
+		"2(switch.done) -next-> 9(label)",
+		"3(switch.case) -fallthrough-> 4(switch.case)",
+		"4(switch.case) -next-> 2(switch.done)",
+		"5(switch.case) -goto-> 9(label)",
+		"6(switch.next) -true-> 4(switch.case)",
+		"6(switch.next) -false-> 7(switch.next)",
+		"7(switch.next) -next-> 5(switch.case)",
+		"9(label) -next-> 1(exit)",
+	}, edges(g))
+	require.Len(t, g.Entry().Nodes, 3)
+}
+
+func TestSelect(t *testing.T) {
+	g := buildOne(t, `package p
+func f(c chan int) {
+	select {
+	case v := <-c:
+		_ = v
+	case c <- 1:
+		break
+	}
+	select {}
+}`)
+	// the empty select blocks forever, thus the function never returns
+	require.Equal(t, []string{
+		"0(entry) -case-> 3(select.case)",
+		"0(entry) -case-> 4(select.case)",
+		"3(select.case) -next-> 2(select.done)",
+		"4(select.case) -break-> 2(select.done)",
+	}, edges(g))
+	require.False(t, g.Exit().Live)
+}
+
+func TestDeferPanic(t *testing.T) {
+	g := buildOne(t, `package p
+func f(x int) {
+	defer a()
+	defer b()
+	if x < 0 {
+		panic("negative")
+	}
+}`)
+	require.Equal(t, []string{
+		"0(entry) -true-> 2(if.then)",
+		"0(entry) -false-> 3(if.done)",
+		"2(if.then) -panic-> 5(defer)",
+		"3(if.done) -next-> 5(defer)",
+		"5(defer) -defer-> 6(defer)",
+		"6(defer) -defer-> 1(exit)",
+	}, edges(g))
+	require.Equal(t, "CallExpr", g.Blocks[5].Nodes[0].Type)
+	require.Equal(t, uint32(44), g.Blocks[5].Nodes[0].Start.Offset)
+}
+
+func TestFuncLit(t *testing.T) {
+	n, err := golang.Parse(`package p
+func f() func() {
+	return func() {}
+}`)
+	require.NoError(t, err)
+	graphs, err := Build(n)
+	require.NoError(t, err)
+	require.Len(t, graphs, 2)
+	require.Equal(t, "FuncLit", graphs[1].Type)
+	require.Equal(t, []string{"0(entry) -next-> 1(exit)"}, edges(graphs[1]))
+
+	data, err := json.Marshal(graphs[1])
+	require.NoError(t, err)
+	var g Graph
+	require.NoError(t, json.Unmarshal(data, &g))
+	require.Equal(t, graphs[1], &g)
+
+	obj := graphs[1].ToNode()
+	require.Equal(t, "cfg:Graph", uast.TypeOf(obj))
+	require.Equal(t, uint32(36), uast.PositionsOf(obj).Start().Offset)
+}
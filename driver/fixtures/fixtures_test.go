@@ -1,13 +1,25 @@
 package fixtures
 
 import (
+	"context"
+	"go/format"
+	"io/ioutil"
+	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/bblfsh/go-driver/driver/golang"
 	"github.com/bblfsh/go-driver/driver/normalizer"
+	"github.com/bblfsh/go-driver/driver/semantic"
 	"github.com/bblfsh/sdk/v3/driver"
 	"github.com/bblfsh/sdk/v3/driver/fixtures"
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+	"github.com/bblfsh/sdk/v3/uast/role"
+	uastyml "github.com/bblfsh/sdk/v3/uast/yaml"
+	"github.com/stretchr/testify/require"
 )
 
 const projectRoot = "../../"
@@ -29,6 +41,9 @@ var Suite = &fixtures.Suite{
 			"ImportSpec",
 			"FuncDecl",
 			"FuncType",
+			"CompositeLit",
+			"KeyValueExpr",
+			"CommentGroup",
 		},
 	},
 }
@@ -40,3 +55,317 @@ func TestGoDriver(t *testing.T) {
 func BenchmarkGoDriver(b *testing.B) {
 	Suite.RunBenchmarks(b)
 }
+
+func TestNodeIDs(t *testing.T) {
+	const code = `package main
+
+import "fmt"
+
+var name string
+
+var greeting = string(name)
+
+type T struct {
+	A, B string ` + "`json:\"a\"`" + `
+}
+
+func (t *T) Get(a, b int) (n int, err error) { return }
+
+func (t T) Set(a, b, c []string) { t.A, t.B = a[0], fmt.Sprint(b, c) }
+
+func split(x int) (a, b int) {
+	defer func() (c, d int) { return }()
+	a, b = x/2, x-x/2
+	return
+}
+`
+	ast := parse(t, code, golang.Options{IDs: true})
+
+	// ids returns IDs of all nodes in the tree, mapped to node types
+	ids := func(n nodes.Node) map[string]string {
+		out := make(map[string]string)
+		nodes.WalkPreOrder(n, func(n nodes.Node) bool {
+			if obj, ok := n.(nodes.Object); ok {
+				if id, ok := obj[golang.KeyID].(nodes.String); ok {
+					_, dup := out[string(id)]
+					require.False(t, dup, "duplicate id: %s", id)
+					out[string(id)] = uast.TypeOf(obj)
+				}
+			}
+			return true
+		})
+		return out
+	}
+	native := ids(ast)
+	require.Equal(t, "Ident", native["Ident:49:57"])
+	require.Equal(t, "CallExpr", native["CallExpr:60:72"])
+
+	for _, mode := range []driver.Mode{driver.ModeAnnotated, driver.ModeSemantic} {
+		out, err := normalizer.Transforms.Do(context.Background(), mode, code, ast)
+		require.NoError(t, err)
+		got := ids(out)
+		for id := range got {
+			require.Contains(t, native, id)
+		}
+		require.Contains(t, got, "CallExpr:60:72")
+		if mode == driver.ModeSemantic {
+			require.Equal(t, "uast:Identifier", got["Ident:49:57"])
+		} else {
+			require.Equal(t, "Ident", got["Ident:49:57"])
+		}
+		// fields split by the normalizer are joined back
+		code, err := normalizer.ToCode(out)
+		require.NoError(t, err)
+		require.Contains(t, code, "func (t *T) Get(a, b int) (n int, err error)")
+	}
+}
+
+func TestToCode(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join(Suite.Path, "*.go.native"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	load := func(name string) nodes.Node {
+		data, err := ioutil.ReadFile(name)
+		require.NoError(t, err)
+		n, err := uastyml.Unmarshal(data)
+		require.NoError(t, err)
+		return n
+	}
+	for _, name := range files {
+		base := strings.TrimSuffix(name, ".native")
+		t.Run(filepath.Base(base), func(t *testing.T) {
+			exp, err := golang.Print(load(name))
+			require.NoError(t, err)
+
+			for _, ext := range []string{".native", ".uast", ".sem.uast"} {
+				if _, err := os.Stat(base + ext); os.IsNotExist(err) {
+					continue
+				}
+				code, err := normalizer.ToCode(load(base + ext))
+				require.NoError(t, err, ext)
+				require.Equal(t, exp, code, ext)
+			}
+		})
+	}
+}
+
+func TestToCodeTransforms(t *testing.T) {
+	const code = `package main
+
+import (
+	_ "embed"
+	"fmt"
+	str "strings"
+)
+
+type T struct {
+	A, B string ` + "`json:\"a\"`" + `
+}
+
+func (t *T) Join(sep string, a, b int, rest ...string) (s string, err error) {
+	s = str.Join(append([]string{t.A, t.B}, rest...), sep)
+	return s + ` + "`\\n`" + ` + fmt.Sprint(a+b), nil
+}
+`
+	ast, err := golang.Parse(code)
+	require.NoError(t, err)
+
+	exp, err := format.Source([]byte(code))
+	require.NoError(t, err)
+	out, err := normalizer.Transforms.Do(context.Background(), driver.ModeAnnotated, code, ast)
+	require.NoError(t, err)
+	got, err := normalizer.ToCode(out)
+	require.NoError(t, err)
+	require.Equal(t, string(exp), got)
+}
+
+// parse parses the code and removes fields that were added to go/ast after the release of Go
+// the fixtures were generated with, since the normalizer does not expect them.
+func parse(t testing.TB, code string, opts golang.Options) nodes.Node {
+	ast, err := golang.ParseWithOptions(code, opts)
+	require.NoError(t, err)
+	nodes.WalkPreOrder(ast, func(n nodes.Node) bool {
+		obj, ok := n.(nodes.Object)
+		if !ok {
+			return true
+		}
+		if v, ok := obj["TypeParams"]; ok && v == nil {
+			delete(obj, "TypeParams")
+		}
+		if v, ok := obj["GoVersion"]; ok && v == nodes.String("") {
+			delete(obj, "GoVersion")
+		}
+		if pos, ok := obj[uast.KeyPos].(nodes.Object); ok {
+			for _, k := range []string{"FileStart", "FileEnd", "ValueEnd", "Range"} {
+				delete(pos, k)
+			}
+		}
+		return true
+	})
+	return ast
+}
+
+func TestStructTag(t *testing.T) {
+	const code = "package main\n\ntype T struct {\n\tA string `json:\"a\"`\n\tB string \"json:\\\"b\\\"\"\n}\n"
+	ast := parse(t, code, golang.Options{})
+	out, err := normalizer.Transforms.Do(context.Background(), driver.ModeSemantic, code, ast)
+	require.NoError(t, err)
+
+	var tags []string
+	nodes.WalkPreOrder(out, func(n nodes.Node) bool {
+		if obj, ok := n.(nodes.Object); ok && uast.TypeOf(obj) == uast.TypeOf(semantic.StructTag{}) {
+			var tag semantic.StructTag
+			require.NoError(t, uast.NodeAs(obj, &tag))
+			require.Len(t, tag.Entries, 1)
+			tags = append(tags, tag.Value)
+		}
+		return true
+	})
+	require.Equal(t, []string{`json:"a"`, `json:"b"`}, tags)
+
+	// both raw and quoted tags survive a round trip
+	got, err := normalizer.ToCode(out)
+	require.NoError(t, err)
+	require.Equal(t, code, got)
+}
+
+func TestVisibility(t *testing.T) {
+	const code = `package main
+
+type Exported struct {
+	Field, field int
+}
+
+type iface interface {
+	Method()
+	method()
+}
+
+func (e Exported) Get() {}
+
+func (e *Exported) get() {}
+
+const C, c = 1, 2
+
+func main() {
+	var Local int
+	_ = Local
+}
+`
+	ast := parse(t, code, golang.Options{})
+	for name, mode := range map[string]driver.Mode{
+		"annotated": driver.ModeAnnotated,
+		"semantic":  driver.ModeSemantic,
+	} {
+		mode := mode
+		t.Run(name, func(t *testing.T) {
+			out, err := normalizer.Transforms.Do(context.Background(), mode, code, ast)
+			require.NoError(t, err)
+
+			// visibility maps names of identifiers with visibility roles to World or Package roles
+			visibility := make(map[string]role.Role)
+			nodes.WalkPreOrder(out, func(n nodes.Node) bool {
+				obj, ok := n.(nodes.Object)
+				if !ok {
+					return true
+				}
+				var name nodes.String
+				switch uast.TypeOf(obj) {
+				case "Ident":
+					name, _ = obj[uast.KeyToken].(nodes.String)
+				case uast.TypeOf(uast.Identifier{}):
+					name, _ = obj["Name"].(nodes.String)
+				default:
+					return true
+				}
+				var vis bool
+				for _, r := range uast.RolesOf(obj) {
+					vis = vis || r == role.Visibility
+				}
+				for _, r := range uast.RolesOf(obj) {
+					if vis && (r == role.World || r == role.Package) {
+						_, dup := visibility[string(name)]
+						require.False(t, dup, "duplicate visibility roles: %s", name)
+						visibility[string(name)] = r
+					}
+				}
+				return true
+			})
+			require.Equal(t, map[string]role.Role{
+				"Exported": role.World,
+				"Field":    role.World,
+				"field":    role.Package,
+				"iface":    role.Package,
+				"Method":   role.World,
+				"method":   role.Package,
+				"Get":      role.World,
+				"get":      role.Package,
+				"C":        role.World,
+				"c":        role.Package,
+				"main":     role.Package,
+			}, visibility)
+		})
+	}
+}
+
+func TestCompositeKinds(t *testing.T) {
+	const code = `package main
+
+type pair []int
+
+func main() {
+	type pair struct {
+		A, B int
+	}
+	_ = pair{1, 2}
+	_ = []pair{{1, 2}}
+	{
+		type pair map[string]int
+		_ = pair{"a": 1}
+	}
+	_ = pair{A: 1}
+}
+
+func other() {
+	_ = pair{1, 2}
+}
+`
+	ast := parse(t, code, golang.Options{})
+	out, err := normalizer.Transforms.Do(context.Background(), driver.ModeSemantic, code, ast)
+	require.NoError(t, err)
+
+	// kinds maps lines of literals to their kinds
+	kinds := make(map[uint32][]string)
+	nodes.WalkPreOrder(out, func(n nodes.Node) bool {
+		obj, ok := n.(nodes.Object)
+		if !ok {
+			return true
+		}
+		var kind string
+		switch uast.TypeOf(obj) {
+		case uast.TypeOf(semantic.RecordLiteral{}):
+			kind = "struct"
+		case uast.TypeOf(semantic.CollectionLiteral{}):
+			kind = string(obj["Kind"].(nodes.String))
+		case uast.TypeOf(semantic.UnknownLiteral{}):
+			kind = "unknown"
+		default:
+			return true
+		}
+		line := uast.PositionsOf(obj).Start().Line
+		kinds[line] = append(kinds[line], kind)
+		return true
+	})
+	for _, k := range kinds {
+		sort.Strings(k)
+	}
+	// local types shadow types of outer blocks
+	require.Equal(t, map[uint32][]string{
+		9:  {"struct"},
+		10: {semantic.KindSlice, "struct"},
+		13: {semantic.KindMap},
+		15: {"struct"},
+		19: {semantic.KindSlice},
+	}, kinds)
+}
@@ -0,0 +1,485 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"go/build"
+	"go/types"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// fieldKind is a way a field of an AST node is converted to and from a native node.
+type fieldKind int
+
+const (
+	// fieldUnsupported fields prevent generation of converters for the node type.
+	fieldUnsupported = fieldKind(iota)
+	// fieldSkip fields are not converted: they are pointers to scopes and objects.
+	fieldSkip
+	fieldPos
+	fieldToken
+	fieldString
+	fieldBool
+	fieldInt
+	// fieldNode is a field of an interface type, like ast.Expr, or a pointer to a node, like *ast.Ident.
+	fieldNode
+	// fieldList is a slice of nodes.
+	fieldList
+)
+
+// convField is a field of an AST node type.
+type convField struct {
+	name string
+	kind fieldKind
+	// typ is the type of the field as it is written in the generated code
+	typ string
+	// elem is the name of the node type for fieldNode and fieldList
+	elem string
+	// ptr is set if the node type for fieldNode and fieldList is a pointer
+	ptr bool
+}
+
+// convType is an AST node type.
+type convType struct {
+	name   string
+	fields []convField
+	// supported is set if converters can be generated for all fields of the type
+	supported bool
+}
+
+// helper is a function that converts native nodes to AST nodes of a given type.
+type helper struct {
+	elem string
+	typ  string
+	list bool
+}
+
+func qualifier(p *types.Package) string {
+	return p.Name()
+}
+
+// classify determines how a field of a given type is converted.
+func classify(name string, t types.Type) convField {
+	f := convField{name: name, typ: types.TypeString(t, qualifier)}
+	switch t := t.(type) {
+	case *types.Named:
+		obj := t.Obj()
+		switch {
+		case obj.Pkg().Path() == "go/token" && obj.Name() == "Pos":
+			f.kind = fieldPos
+		case obj.Pkg().Path() == "go/token" && obj.Name() == "Token":
+			f.kind = fieldToken
+		case obj.Pkg().Path() == "go/ast" && types.IsInterface(t):
+			f.kind, f.elem = fieldNode, obj.Name()
+		default:
+			if b, ok := t.Underlying().(*types.Basic); ok && b.Info()&types.IsInteger != 0 {
+				f.kind = fieldInt
+			}
+		}
+	case *types.Pointer:
+		if el, ok := t.Elem().(*types.Named); ok && el.Obj().Pkg().Path() == "go/ast" {
+			switch el.Obj().Name() {
+			case "Scope", "Object":
+				f.kind = fieldSkip
+			default:
+				f.kind, f.elem, f.ptr = fieldNode, el.Obj().Name(), true
+			}
+		}
+	case *types.Slice:
+		if el := classify(name, t.Elem()); el.kind == fieldNode {
+			f.kind, f.elem, f.ptr = fieldList, el.elem, el.ptr
+		}
+	case *types.Basic:
+		switch {
+		case t.Kind() == types.String:
+			f.kind = fieldString
+		case t.Kind() == types.Bool:
+			f.kind = fieldBool
+		case t.Info()&types.IsInteger != 0:
+			f.kind = fieldInt
+		}
+	}
+	return f
+}
+
+// apiChanges lists go/ast types and fields added after the minimum supported release of Go.
+type apiChanges struct {
+	// types maps names of added types to releases that added them
+	types map[string]string
+	// fields is a set of added fields, in the form of "Type.Field"
+	fields map[string]struct{}
+}
+
+var reAPIStruct = regexp.MustCompile(`^pkg go/ast, type (\w+) struct(?:, (\w+) .*)?$`)
+
+// loadAPIChanges reads API files of the Go distribution the generator is executed with and returns
+// changes of go/ast made after a given release, like "go1.13".
+func loadAPIChanges(min string) (*apiChanges, error) {
+	minor, err := releaseMinor(min)
+	if err != nil {
+		return nil, err
+	}
+	files, err := filepath.Glob(filepath.Join(build.Default.GOROOT, "api", "go1.*.txt"))
+	if err != nil {
+		return nil, err
+	} else if len(files) == 0 {
+		return nil, fmt.Errorf("no API files found in %s", build.Default.GOROOT)
+	}
+	api := &apiChanges{types: make(map[string]string), fields: make(map[string]struct{})}
+	for _, name := range files {
+		rel := strings.TrimSuffix(filepath.Base(name), ".txt")
+		if v, err := releaseMinor(rel); err != nil || v <= minor {
+			continue
+		}
+		if err := api.read(name, rel); err != nil {
+			return nil, err
+		}
+	}
+	return api, nil
+}
+
+// read adds go/ast changes listed in the API file of a given release.
+func (api *apiChanges) read(name, rel string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		// strip references to issues
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		sub := reAPIStruct.FindStringSubmatch(line)
+		if sub == nil {
+			continue
+		}
+		if sub[2] == "" {
+			api.types[sub[1]] = rel
+		} else {
+			api.fields[sub[1]+"."+sub[2]] = struct{}{}
+		}
+	}
+	return sc.Err()
+}
+
+// releaseMinor returns the minor version of a Go release, like 13 for "go1.13".
+func releaseMinor(rel string) (int, error) {
+	v, err := strconv.Atoi(strings.TrimPrefix(rel, "go1."))
+	if err != nil || !strings.HasPrefix(rel, "go1.") {
+		return 0, fmt.Errorf("invalid Go release: %q", rel)
+	}
+	return v, nil
+}
+
+// convTypes describes node types for the converters. Types and fields added to go/ast after the minimum
+// supported release are not described: the former are returned separately, grouped by the release
+// that added them, and the latter are converted via reflection.
+func convTypes(list []*types.Named, api *apiChanges) ([]convType, map[string][]string) {
+	var out []convType
+	later := make(map[string][]string)
+	for _, n := range list {
+		st, ok := n.Underlying().(*types.Struct)
+		if !ok {
+			continue
+		}
+		name := n.Obj().Name()
+		if rel, ok := api.types[name]; ok {
+			later[rel] = append(later[rel], name)
+			continue
+		}
+		t := convType{name: name, supported: true}
+		for i := 0; i < st.NumFields(); i++ {
+			v := st.Field(i)
+			if _, ok := api.fields[name+"."+v.Name()]; ok {
+				continue
+			}
+			f := classify(v.Name(), v.Type())
+			if f.kind == fieldUnsupported {
+				t.supported = false
+			}
+			t.fields = append(t.fields, f)
+		}
+		out = append(out, t)
+	}
+	return out, later
+}
+
+func lowerFirst(s string) string {
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// registryExtra lists types that are not AST nodes, but are registered for compatibility.
+var registryExtra = []string{"CommentMap", "Object", "Scope"}
+
+// genConv generates the registry of AST node types and converters of AST nodes to native nodes and back.
+// The code only refers to types and fields of the minimum supported release of Go.
+func genConv(w io.Writer, list []convType) {
+	fmt.Fprint(w, `package golang
+
+import (
+	"go/ast"
+	"reflect"
+
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+)
+
+func init() {
+`)
+	names := append([]string{}, registryExtra...)
+	for _, t := range list {
+		names = append(names, t.name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "\tregisterType(%q, ast.%s{})\n", name, name)
+	}
+	fmt.Fprint(w, `}
+
+// astFields lists fields of AST node types known to the converter, in the order of declaration.
+// Fields added to go/ast later are converted via reflection (see extraFields).
+var astFields = map[string][]string{
+`)
+	for _, t := range list {
+		if !t.supported {
+			continue
+		}
+		names := make([]string, 0, len(t.fields))
+		for _, f := range t.fields {
+			names = append(names, fmt.Sprintf("%q", f.name))
+		}
+		fmt.Fprintf(w, "\t%q: {%s},\n", t.name, strings.Join(names, ", "))
+	}
+	fmt.Fprint(w, "}\n")
+
+	lists := make(map[string]helper)
+	helpers := make(map[string]helper)
+	genToNode(w, list, lists)
+	genToAST(w, list, helpers)
+
+	for _, name := range sortedKeys(lists) {
+		h := lists[name]
+		fmt.Fprintf(w, `
+func (c *converter) %s(obj nodes.Object, key string, list %s, depth int) {
+	if len(list) == 0 {
+		obj[key] = nil
+		return
+	}
+	arr := make(nodes.Array, len(list))
+	for i, n := range list {
+		if n != nil {
+			c.elem(arr, i, n, depth)
+		}
+	}
+	obj[key] = arr
+}
+`, name, h.typ)
+	}
+	for _, name := range sortedKeys(helpers) {
+		h := helpers[name]
+		if h.list {
+			fmt.Fprintf(w, `
+func %s(v nodes.Node) %s {
+	if v == nil {
+		return nil
+	}
+	arr := v.(nodes.Array)
+	list := make(%s, len(arr))
+	for i, e := range arr {
+		list[i] = to%s(e)
+	}
+	return list
+}
+`, name, h.typ, h.typ, h.elem)
+			continue
+		}
+		zero := "nil"
+		if h.elem == "FuncType" {
+			// see NodeToAST
+			zero = "&ast.FuncType{}"
+		}
+		fmt.Fprintf(w, `
+func %s(v nodes.Node) %s {
+	if v == nil {
+		return %s
+	}
+	return astNode(v).(%s)
+}
+`, name, h.typ, zero, h.typ)
+	}
+}
+
+// genToNode generates the conversion of AST nodes to native nodes. Names of helpers for lists are
+// added to the map.
+func genToNode(w io.Writer, list []convType, lists map[string]helper) {
+	fmt.Fprint(w, `
+// node converts a single AST node at a given nesting level, and queues the conversion of its children.
+// Nodes of unknown types are converted via reflection.
+func (c *converter) node(n ast.Node, depth int) (nodes.Node, error) {
+	switch n := n.(type) {
+`)
+	for _, t := range list {
+		if !t.supported {
+			continue
+		}
+		var pos []string
+		size := 2
+		for _, f := range t.fields {
+			switch f.kind {
+			case fieldPos:
+				pos = append(pos, f.name)
+			case fieldSkip:
+			default:
+				size++
+			}
+		}
+		fmt.Fprintf(w, `	case *ast.%s:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, %d)
+		obj[uast.KeyType] = nodes.String(%q)
+		pos := c.positions(n, %d)
+`, t.name, size, t.name, len(pos))
+		for _, p := range pos {
+			fmt.Fprintf(w, "\t\tpos[%q] = c.position(n.%s)\n", p, p)
+		}
+		fmt.Fprint(w, "\t\tobj[uast.KeyPos] = pos\n")
+		for _, f := range t.fields {
+			switch f.kind {
+			case fieldToken:
+				fmt.Fprintf(w, "\t\tobj[%q] = nodes.String(n.%s.String())\n", f.name, f.name)
+			case fieldString:
+				fmt.Fprintf(w, "\t\tobj[%q] = nodes.String(n.%s)\n", f.name, f.name)
+			case fieldBool:
+				fmt.Fprintf(w, "\t\tobj[%q] = nodes.Bool(n.%s)\n", f.name, f.name)
+			case fieldInt:
+				fmt.Fprintf(w, "\t\tobj[%q] = nodes.Int(n.%s)\n", f.name, f.name)
+			case fieldNode:
+				if !f.ptr {
+					fmt.Fprintf(w, "\t\tc.field(obj, %q, n.%s, depth)\n", f.name, f.name)
+					continue
+				}
+				fmt.Fprintf(w, `		obj[%q] = nil
+		if n.%s != nil {
+			c.field(obj, %q, n.%s, depth)
+		}
+`, f.name, f.name, f.name, f.name)
+			case fieldList:
+				name := lowerFirst(f.elem) + "s"
+				lists[name] = helper{elem: f.elem, typ: f.typ, list: true}
+				fmt.Fprintf(w, "\t\tc.%s(obj, %q, n.%s, depth)\n", name, f.name, f.name)
+			}
+		}
+		fmt.Fprint(w, "\t\treturn c.extra(obj, pos, n, depth)\n")
+	}
+	fmt.Fprint(w, `	}
+	c.depth = depth - 1
+	return c.toNode(reflect.ValueOf(n))
+}
+`)
+}
+
+// genToAST generates the conversion of native nodes to AST nodes. Names of conversion helpers are added to the map.
+func genToAST(w io.Writer, list []convType, helpers map[string]helper) {
+	fmt.Fprint(w, `
+// objectToAST converts a native node to an AST node without reflection. It returns false for unknown node types.
+// Fields that are not known to the converter are set via reflection.
+func objectToAST(o nodes.Object) (ast.Node, bool) {
+	typ := uast.TypeOf(o)
+	switch typ {
+`)
+	for _, t := range list {
+		if !t.supported {
+			continue
+		}
+		fmt.Fprintf(w, `	case %q:
+		n := &ast.%s{}
+		for k, v := range o {
+			switch k {
+`, t.name, t.name)
+		for _, f := range t.fields {
+			var conv string
+			switch f.kind {
+			case fieldToken:
+				conv = "tokens[string(v.(nodes.String))]"
+			case fieldString:
+				conv = "string(v.(nodes.String))"
+			case fieldBool:
+				conv = "bool(v.(nodes.Bool))"
+			case fieldInt:
+				conv = f.typ + "(v.(nodes.Int))"
+			case fieldNode:
+				name := "to" + f.elem
+				helpers[name] = helper{elem: f.elem, typ: f.typ}
+				conv = name + "(v)"
+			case fieldList:
+				name := "to" + f.elem + "s"
+				helpers[name] = helper{elem: f.elem, typ: f.typ, list: true}
+				el := "to" + f.elem
+				if _, ok := helpers[el]; !ok {
+					typ := "ast." + f.elem
+					if f.ptr {
+						typ = "*" + typ
+					}
+					helpers[el] = helper{elem: f.elem, typ: typ}
+				}
+				conv = name + "(v)"
+			default:
+				continue
+			}
+			fmt.Fprintf(w, "\t\t\tcase %q:\n\t\t\t\tn.%s = %s\n", f.name, f.name, conv)
+		}
+		fmt.Fprint(w, `			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+`)
+	}
+	fmt.Fprint(w, `	}
+	return nil, false
+}
+`)
+}
+
+// genRegistry generates the registry of AST node types added to go/ast by a given release of Go.
+// The code is only compiled by this release or a later one, and nodes of these types are converted
+// via reflection.
+func genRegistry(w io.Writer, rel string, names []string) {
+	fmt.Fprintf(w, `//go:build %[1]s
+// +build %[1]s
+
+package golang
+
+import "go/ast"
+
+func init() {
+`, rel)
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "\tregisterType(%q, ast.%s{})\n", name, name)
+	}
+	fmt.Fprint(w, "}\n")
+}
+
+func sortedKeys(m map[string]helper) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
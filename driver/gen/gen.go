@@ -1,15 +1,20 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"go/ast"
+	"go/format"
 	"go/token"
 	"go/types"
 	"io"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"sort"
+	"strings"
 
 	"golang.org/x/tools/go/loader"
 )
@@ -37,13 +42,13 @@ func loadPackage(name string) (*loader.PackageInfo, error) {
 }
 
 func genFile(name string, gen func(w io.Writer)) error {
-	file, err := os.Create(name)
+	buf := new(bytes.Buffer)
+	gen(buf)
+	data, err := format.Source(buf.Bytes())
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-	gen(file)
-	return nil
+	return ioutil.WriteFile(name, data, 0644)
 }
 
 type byName []*types.Named
@@ -62,6 +67,8 @@ func (a byName) Swap(i, j int) {
 
 var (
 	fAnnAuto = flag.String("ann", "./normalizer/ann_gen.go", "file for auto annotations")
+	fConv    = flag.String("conv", "./golang/convert_gen.go", "file for conversion of AST nodes")
+	fGo      = flag.String("go", "go1.13", "minimum supported release of Go; AST types and fields added later are converted via reflection")
 )
 
 func main() {
@@ -124,7 +131,7 @@ func main() {
 
 	const genHeader = "// Code generated by ./gen/gen.go DO NOT EDIT.\n\n"
 
-	genFile(*fAnnAuto, func(w io.Writer) {
+	err = genFile(*fAnnAuto, func(w io.Writer) {
 		fmt.Fprintln(w, genHeader+`package normalizer
 
 import (
@@ -147,4 +154,43 @@ var typeRoles = map[string][]role.Role{`)
 		genRole("Expr", "Expression", expr)
 		genRole("Stmt", "Statement", stmt)
 	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	api, err := loadAPIChanges(*fGo)
+	if err != nil {
+		log.Fatal(err)
+	}
+	conv, later := convTypes(nodes, api)
+	err = genFile(*fConv, func(w io.Writer) {
+		fmt.Fprint(w, genHeader)
+		genConv(w, conv)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// registries of node types added by newer releases of Go are generated into separate files,
+	// named like convert_go118_gen.go
+	dir := filepath.Dir(*fConv)
+	old, err := filepath.Glob(filepath.Join(dir, "convert_go*_gen.go"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, name := range old {
+		if err = os.Remove(name); err != nil {
+			log.Fatal(err)
+		}
+	}
+	for rel, names := range later {
+		name := filepath.Join(dir, "convert_"+strings.Replace(rel, ".", "", 1)+"_gen.go")
+		err = genFile(name, func(w io.Writer) {
+			fmt.Fprint(w, genHeader)
+			genRegistry(w, rel, names)
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 }
@@ -0,0 +1,60 @@
+package golang
+
+import (
+	"go/token"
+
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+)
+
+// analysisFields lists fields of native nodes that are set by optional analyses (see Options).
+// These fields have no counterparts in the Go AST and are ignored by NodeToAST.
+var analysisFields = map[string][]string{
+	"File":     {fieldCalls, fieldScopes},
+	"FuncDecl": {fieldMetrics},
+	"FuncLit":  {fieldCaptures, fieldLoopVarCapture, fieldMetrics},
+}
+
+func isAnalysisField(typ, field string) bool {
+	for _, f := range analysisFields[typ] {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// attachFields sets additional fields on native nodes of a given type. Nodes are matched by
+// the start position, since there are no two nodes of the same type starting at the same offset.
+func attachFields(root nodes.Node, fs *token.FileSet, typ string, fields map[token.Pos]nodes.Object) nodes.Node {
+	if len(fields) == 0 {
+		return root
+	}
+	byOffset := make(map[uint32]nodes.Object, len(fields))
+	for p, f := range fields {
+		byOffset[convertPosition(p, fs).Offset] = f
+	}
+	nn, ok := nodes.Apply(root, func(n nodes.Node) (nodes.Node, bool) {
+		obj, ok := n.(nodes.Object)
+		if !ok || uast.TypeOf(obj) != typ {
+			return n, false
+		}
+		start := uast.PositionsOf(obj).Start()
+		if start == nil {
+			return n, false
+		}
+		f, ok := byOffset[start.Offset]
+		if !ok {
+			return n, false
+		}
+		obj = obj.CloneObject()
+		for k, v := range f {
+			obj[k] = v
+		}
+		return obj, true
+	})
+	if !ok {
+		return root
+	}
+	return nn
+}
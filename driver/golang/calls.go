@@ -0,0 +1,318 @@
+package golang
+
+import (
+	"go/ast"
+	"go/token"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+)
+
+const (
+	// typeCall is a type of native nodes that describe an edge of the call graph.
+	typeCall = "Call"
+
+	// fieldCalls is a field of File set by the call graph analysis.
+	fieldCalls = "Calls"
+)
+
+// Kinds of callees.
+const (
+	// calleeFunction is a function declared in the package or imported from another package.
+	calleeFunction = "function"
+	// calleeMethod is a method of a concrete type.
+	calleeMethod = "method"
+	// calleeInterface is a method of an interface; the actual callee is only known at runtime.
+	calleeInterface = "interface"
+	// calleeValue is a variable or a field of a function type, or a result of an expression.
+	calleeValue = "value"
+	// calleeLiteral is a function literal that is called in place.
+	calleeLiteral = "literal"
+	// calleeBuiltin is a builtin function, like len or append.
+	calleeBuiltin = "builtin"
+)
+
+// callInfo is an edge of the call graph.
+type callInfo struct {
+	call *ast.CallExpr
+	// caller is a name of the enclosing function declaration; it is empty for package-level initializers
+	caller string
+	// callee is a name of the called function; methods are prefixed with the type name
+	callee string
+	kind   string
+	// pkg is an import path of the callee if it is declared in another package
+	pkg string
+}
+
+// dynamic reports if the callee can only be resolved at runtime.
+func (c *callInfo) dynamic() bool {
+	return c.kind == calleeInterface || c.kind == calleeValue
+}
+
+// calls resolves callees of all call expressions in the file and returns caller→callee edges
+// as a field of the File node, indexed by its position. Type conversions are not listed.
+//
+// Callees are resolved syntactically: the parser links identifiers to declarations in the same file,
+// and types of variables are inferred from their declarations, composite literals and calls to new.
+// Identifiers that are not declared in the file are assumed to be functions of the same package,
+// and methods of types declared in other packages are assumed to be methods of concrete types.
+func calls(f *ast.File, fs *token.FileSet) map[token.Pos]nodes.Object {
+	r := callResolver{imports: make(map[string]string)}
+	for _, imp := range f.Imports {
+		p, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := importName(p)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		r.imports[name] = p
+	}
+	var list []*callInfo
+	for _, decl := range f.Decls {
+		caller := ""
+		if fd, ok := decl.(*ast.FuncDecl); ok {
+			caller = fd.Name.Name
+			if fd.Recv != nil && len(fd.Recv.List) != 0 {
+				if name, _ := exprTypeName(fd.Recv.List[0].Type); name != nil {
+					caller = name.Name + "." + caller
+				}
+			}
+		}
+		ast.Inspect(decl, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			if c := r.resolve(call); c != nil {
+				c.caller = caller
+				list = append(list, c)
+			}
+			return true
+		})
+	}
+	arr := make(nodes.Array, 0, len(list))
+	for _, c := range list {
+		arr = append(arr, c.toNode(fs))
+	}
+	return map[token.Pos]nodes.Object{
+		f.Pos(): {fieldCalls: arr},
+	}
+}
+
+func (c *callInfo) toNode(fs *token.FileSet) nodes.Object {
+	obj := nodes.Object{
+		uast.KeyType: nodes.String(typeCall),
+		uast.KeyPos:  nodePositions(c.call, fs).ToObject(),
+		"Caller":     nodes.String(c.caller),
+		"Callee":     nodes.String(c.callee),
+		"Kind":       nodes.String(c.kind),
+		"Dynamic":    nodes.Bool(c.dynamic()),
+	}
+	if c.pkg != "" {
+		obj["Package"] = nodes.String(c.pkg)
+	}
+	return obj
+}
+
+// callResolver finds callees of call expressions in a single file.
+type callResolver struct {
+	// imports maps local names of imported packages to import paths
+	imports map[string]string
+}
+
+// resolve returns a callee of the call, or nil if the call is a type conversion.
+func (r *callResolver) resolve(call *ast.CallExpr) *callInfo {
+	c := &callInfo{call: call}
+	switch fun := unparen(call.Fun).(type) {
+	case *ast.Ident:
+		c.callee = fun.Name
+		switch {
+		case fun.Obj == nil:
+			switch universeKind(fun.Name) {
+			case ast.Typ:
+				// conversion to a predeclared type
+				return nil
+			case ast.Fun:
+				c.kind = calleeBuiltin
+			default:
+				c.kind = calleeFunction
+			}
+		case fun.Obj.Kind == ast.Fun:
+			c.kind = calleeFunction
+		case fun.Obj.Kind == ast.Typ:
+			return nil
+		default:
+			c.kind = calleeValue
+		}
+	case *ast.SelectorExpr:
+		r.resolveSelector(c, fun)
+	case *ast.FuncLit:
+		c.kind = calleeLiteral
+	case *ast.ArrayType, *ast.ChanType, *ast.FuncType, *ast.InterfaceType, *ast.MapType, *ast.StructType, *ast.StarExpr:
+		// conversion to an unnamed type
+		return nil
+	default:
+		c.kind = calleeValue
+	}
+	return c
+}
+
+// resolveSelector resolves a callee of the form X.Sel.
+func (r *callResolver) resolveSelector(c *callInfo, sel *ast.SelectorExpr) {
+	name := sel.Sel.Name
+	x, ok := unparen(sel.X).(*ast.Ident)
+	if !ok {
+		// a method of a result of an expression
+		c.callee, c.kind = name, calleeValue
+		return
+	}
+	if x.Obj == nil {
+		if p, ok := r.imports[x.Name]; ok {
+			// package-qualified function, or a conversion to a type of another package;
+			// the latter cannot be distinguished without type information
+			c.callee, c.kind, c.pkg = name, calleeFunction, p
+			return
+		}
+	}
+	var typ ast.Expr
+	if x.Obj != nil {
+		switch x.Obj.Kind {
+		case ast.Typ:
+			// method expression
+			typ = x
+		case ast.Var:
+			typ = varType(x.Obj)
+		}
+	}
+	tname, pkg := exprTypeName(typ)
+	if tname == nil {
+		c.callee, c.kind = name, calleeValue
+		return
+	}
+	c.callee, c.kind = tname.Name+"."+name, calleeMethod
+	if pkg != nil {
+		c.pkg = r.imports[pkg.Name]
+		return
+	}
+	if tname.Obj == nil {
+		return
+	}
+	spec, ok := tname.Obj.Decl.(*ast.TypeSpec)
+	if !ok {
+		return
+	}
+	switch t := spec.Type.(type) {
+	case *ast.InterfaceType:
+		c.kind = calleeInterface
+	case *ast.StructType:
+		// a field of a function type
+		for _, f := range t.Fields.List {
+			for _, id := range f.Names {
+				if id.Name == name {
+					c.kind = calleeValue
+				}
+			}
+		}
+	}
+}
+
+// varType returns a type expression of a variable, or nil if it cannot be inferred from the declaration.
+func varType(obj *ast.Object) ast.Expr {
+	switch decl := obj.Decl.(type) {
+	case *ast.Field:
+		return decl.Type
+	case *ast.ValueSpec:
+		if decl.Type != nil {
+			return decl.Type
+		}
+		for i, id := range decl.Names {
+			if id.Obj == obj && i < len(decl.Values) && len(decl.Values) == len(decl.Names) {
+				return exprType(decl.Values[i])
+			}
+		}
+	case *ast.AssignStmt:
+		for i, e := range decl.Lhs {
+			if id, ok := e.(*ast.Ident); ok && id.Obj == obj && len(decl.Rhs) == len(decl.Lhs) {
+				return exprType(decl.Rhs[i])
+			}
+		}
+	}
+	return nil
+}
+
+// exprType returns a type of an expression for composite literals, calls to new and conversions.
+func exprType(e ast.Expr) ast.Expr {
+	switch e := unparen(e).(type) {
+	case *ast.CompositeLit:
+		return e.Type
+	case *ast.UnaryExpr:
+		if e.Op == token.AND {
+			return exprType(e.X)
+		}
+	case *ast.CallExpr:
+		if id, ok := unparen(e.Fun).(*ast.Ident); ok {
+			if id.Obj == nil && id.Name == "new" && len(e.Args) == 1 {
+				return e.Args[0]
+			} else if id.Obj != nil && id.Obj.Kind == ast.Typ {
+				return id
+			}
+		}
+	}
+	return nil
+}
+
+// exprTypeName returns the name of a named type, and the package name for qualified types.
+// Pointers and type arguments are ignored.
+func exprTypeName(e ast.Expr) (name, pkg *ast.Ident) {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return e, nil
+	case *ast.StarExpr:
+		return exprTypeName(e.X)
+	case *ast.ParenExpr:
+		return exprTypeName(e.X)
+	case *ast.IndexExpr:
+		return exprTypeName(e.X)
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok {
+			return e.Sel, x
+		}
+	default:
+		if x := genericType(e); x != nil {
+			return exprTypeName(x)
+		}
+	}
+	return nil, nil
+}
+
+// genericType returns the generic type of the expression that instantiates it with multiple
+// type arguments. Such expressions were added in Go 1.18 (see calls_go118.go).
+var genericType = func(e ast.Expr) ast.Expr { return nil }
+
+func unparen(e ast.Expr) ast.Expr {
+	for {
+		p, ok := e.(*ast.ParenExpr)
+		if !ok {
+			return e
+		}
+		e = p.X
+	}
+}
+
+// importName returns a default name of an imported package. Version suffixes like "/v2"
+// and ".v2" are not a part of the name.
+func importName(p string) string {
+	name := path.Base(p)
+	if len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = path.Base(path.Dir(p))
+	}
+	if i := strings.Index(name, ".v"); i > 0 {
+		name = name[:i]
+	}
+	return strings.ReplaceAll(name, "-", "_")
+}
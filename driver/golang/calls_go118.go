@@ -0,0 +1,15 @@
+//go:build go1.18
+// +build go1.18
+
+package golang
+
+import "go/ast"
+
+func init() {
+	genericType = func(e ast.Expr) ast.Expr {
+		if e, ok := e.(*ast.IndexListExpr); ok {
+			return e.X
+		}
+		return nil
+	}
+}
@@ -0,0 +1,174 @@
+package golang
+
+import (
+	"go/ast"
+	"go/token"
+
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+)
+
+const (
+	// typeCapture is a type of native nodes that describe variables captured by a function literal.
+	typeCapture = "Capture"
+
+	// FuncLit fields set by the captures analysis.
+	fieldCaptures       = "Captures"
+	fieldLoopVarCapture = "LoopVarCapture"
+)
+
+// captureInfo describes a single variable captured by a function literal.
+type captureInfo struct {
+	obj  *ast.Object
+	decl *ast.Ident
+	uses []*ast.Ident
+	// loop is set if the variable is declared by a header of a for or range loop
+	loop bool
+}
+
+// captures finds variables of enclosing functions captured by each function literal in the file.
+// The result is a set of fields for each function literal, indexed by its position.
+//
+// Variables are resolved by the parser, thus only identifiers declared in the same file are
+// considered; package-level variables are not captures, since they are shared anyway.
+//
+// Function literals that are a part of go and defer statements and capture a variable of an
+// enclosing loop are flagged with LoopVarCapture. Before Go 1.22 all iterations of a loop
+// share the same variable, thus such function literals usually observe its last value.
+func captures(f *ast.File, fs *token.FileSet) map[token.Pos]nodes.Object {
+	var (
+		loopVars = make(map[*ast.Object]struct{})
+		deferred = make(map[*ast.FuncLit]struct{})
+		lits     []*ast.FuncLit
+	)
+	loopVar := func(e ast.Expr) {
+		if id, ok := e.(*ast.Ident); ok && id.Obj != nil {
+			loopVars[id.Obj] = struct{}{}
+		}
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			lits = append(lits, n)
+		case *ast.ForStmt:
+			if as, ok := n.Init.(*ast.AssignStmt); ok && as.Tok == token.DEFINE {
+				for _, e := range as.Lhs {
+					loopVar(e)
+				}
+			}
+		case *ast.RangeStmt:
+			if n.Tok == token.DEFINE {
+				loopVar(n.Key)
+				loopVar(n.Value)
+			}
+		case *ast.GoStmt:
+			deferredLits(n.Call, deferred)
+		case *ast.DeferStmt:
+			deferredLits(n.Call, deferred)
+		}
+		return true
+	})
+	if len(lits) == 0 {
+		return nil
+	}
+	out := make(map[token.Pos]nodes.Object, len(lits))
+	for _, lit := range lits {
+		var (
+			list  []*captureInfo
+			byObj = make(map[*ast.Object]*captureInfo)
+		)
+		ast.Inspect(lit.Body, func(n ast.Node) bool {
+			id, ok := n.(*ast.Ident)
+			if !ok || id.Obj == nil || id.Obj.Kind != ast.Var {
+				return true
+			}
+			if c := byObj[id.Obj]; c != nil {
+				c.uses = append(c.uses, id)
+				return true
+			}
+			if f.Scope != nil && f.Scope.Lookup(id.Name) == id.Obj {
+				// package-level variable
+				return true
+			}
+			decl := declIdent(id.Obj)
+			if decl == nil || (lit.Pos() <= decl.Pos() && decl.Pos() < lit.End()) {
+				// declared inside the function literal
+				return true
+			}
+			_, loop := loopVars[id.Obj]
+			c := &captureInfo{obj: id.Obj, decl: decl, uses: []*ast.Ident{id}, loop: loop}
+			byObj[id.Obj] = c
+			list = append(list, c)
+			return true
+		})
+		arr := make(nodes.Array, 0, len(list))
+		loopCapture := false
+		for _, c := range list {
+			arr = append(arr, c.toNode(fs))
+			loopCapture = loopCapture || c.loop
+		}
+		_, isDeferred := deferred[lit]
+		out[lit.Pos()] = nodes.Object{
+			fieldCaptures:       arr,
+			fieldLoopVarCapture: nodes.Bool(loopCapture && isDeferred),
+		}
+	}
+	return out
+}
+
+// deferredLits collects function literals that are executed by go or defer statements:
+// either called directly or passed as arguments of the call. Literals nested in them
+// are not collected, since they may be called synchronously.
+func deferredLits(call *ast.CallExpr, out map[*ast.FuncLit]struct{}) {
+	if lit, ok := call.Fun.(*ast.FuncLit); ok {
+		out[lit] = struct{}{}
+	}
+	for _, arg := range call.Args {
+		if lit, ok := arg.(*ast.FuncLit); ok {
+			out[lit] = struct{}{}
+		}
+	}
+}
+
+// declIdent finds an identifier that declares the object.
+func declIdent(obj *ast.Object) *ast.Ident {
+	decl, ok := obj.Decl.(ast.Node)
+	if !ok {
+		return nil
+	}
+	var found *ast.Ident
+	ast.Inspect(decl, func(n ast.Node) bool {
+		if found != nil {
+			return false
+		}
+		if id, ok := n.(*ast.Ident); ok && id.Obj == obj && id.Name == obj.Name {
+			found = id
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// toNode converts the capture to a native node. Positions of the node point to the declaration
+// of the variable, and Uses lists positions of all its references in the function literal.
+func (c *captureInfo) toNode(fs *token.FileSet) nodes.Object {
+	uses := make(nodes.Array, 0, len(c.uses))
+	for _, id := range c.uses {
+		uses = append(uses, nodePositions(id, fs).ToObject())
+	}
+	return nodes.Object{
+		uast.KeyType: nodes.String(typeCapture),
+		uast.KeyPos:  nodePositions(c.decl, fs).ToObject(),
+		"Name":       nodes.String(c.obj.Name),
+		"Uses":       uses,
+		"LoopVar":    nodes.Bool(c.loop),
+	}
+}
+
+func nodePositions(n ast.Node, fs *token.FileSet) uast.Positions {
+	return uast.Positions{
+		uast.KeyStart: convertPosition(n.Pos(), fs),
+		uast.KeyEnd:   convertPosition(n.End(), fs),
+	}
+}
@@ -0,0 +1,109 @@
+package golang
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+)
+
+// KeyLines is a reserved key of the File node that stores the line table when positions are compact
+// (see Options.CompactPositions). The table lists offsets of the first byte of each line.
+const KeyLines = "@lines"
+
+// lineTable returns offsets of the first byte of each line of the source code.
+func lineTable(code string) nodes.Array {
+	lines := make(nodes.Array, 1, strings.Count(code, "\n")+1)
+	lines[0] = nodes.Uint(0)
+	for i := 0; i < len(code); i++ {
+		if code[i] == '\n' {
+			lines = append(lines, nodes.Uint(i+1))
+		}
+	}
+	return lines
+}
+
+// compactPositions replaces positions of all nodes in the tree with compact ones: only start and end
+// positions are kept, and only their offsets. Invalid positions are removed. The tree is modified in place.
+func compactPositions(root nodes.Node) {
+	switch n := root.(type) {
+	case nodes.Array:
+		for _, v := range n {
+			compactPositions(v)
+		}
+	case nodes.Object:
+		for k, v := range n {
+			if k != uast.KeyPos {
+				compactPositions(v)
+				continue
+			}
+			ps, ok := v.(nodes.Object)
+			if !ok || uast.TypeOf(ps) != uast.TypePositions {
+				continue
+			}
+			pos := make(nodes.Object, 3)
+			pos[uast.KeyType] = ps[uast.KeyType]
+			for _, key := range []string{uast.KeyStart, uast.KeyEnd} {
+				p, _ := ps[key].(nodes.Object)
+				if line, _ := p["line"].(nodes.Uint); line == 0 {
+					continue
+				}
+				pos[key] = nodes.Object{
+					uast.KeyType: nodes.String(uast.TypePosition),
+					"offset":     p["offset"],
+				}
+			}
+			n[k] = pos
+		}
+	}
+}
+
+// ExpandPositions restores lines and columns of positions of a native tree returned with
+// Options.CompactPositions, using the line table of the File node. The line table is removed,
+// and the tree is modified in place. Positions of fields of AST nodes are not restored.
+func ExpandPositions(root nodes.Node) error {
+	file, ok := root.(nodes.Object)
+	if !ok || uast.TypeOf(file) != "File" {
+		return errors.New("go driver: expected a File node")
+	}
+	arr, ok := file[KeyLines].(nodes.Array)
+	if !ok || len(arr) == 0 {
+		return errors.New("go driver: no line table in the File node")
+	}
+	lines := make([]uint32, 0, len(arr))
+	for _, v := range arr {
+		off, ok := v.(nodes.Uint)
+		if !ok {
+			return errors.New("go driver: invalid line table in the File node")
+		}
+		lines = append(lines, uint32(off))
+	}
+	delete(file, KeyLines)
+	expandPositions(root, lines)
+	return nil
+}
+
+func expandPositions(root nodes.Node, lines []uint32) {
+	switch n := root.(type) {
+	case nodes.Array:
+		for _, v := range n {
+			expandPositions(v, lines)
+		}
+	case nodes.Object:
+		if uast.TypeOf(n) == uast.TypePosition {
+			off, _ := n["offset"].(nodes.Uint)
+			// the number of lines that start at or before the offset
+			line := sort.Search(len(lines), func(i int) bool {
+				return lines[i] > uint32(off)
+			})
+			n["line"] = nodes.Uint(line)
+			n["col"] = nodes.Uint(uint32(off) - lines[line-1] + 1)
+			return
+		}
+		for _, v := range n {
+			expandPositions(v, lines)
+		}
+	}
+}
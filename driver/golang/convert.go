@@ -1,6 +1,8 @@
 package golang
 
 import (
+	"context"
+	"fmt"
 	"go/ast"
 	"go/token"
 	"reflect"
@@ -18,69 +20,9 @@ var (
 	UASTIntType = reflect.TypeOf(nodes.Int(0))
 )
 
+// typeNameToType maps names of AST node types to Go types (see registerType).
 var typeNameToType = make(map[string]reflect.Type)
 
-func init() {
-	registerType("ArrayType", ast.ArrayType{})
-	registerType("AssignStmt", ast.AssignStmt{})
-	registerType("BadDecl", ast.BadDecl{})
-	registerType("BadExpr", ast.BadExpr{})
-	registerType("BadStmt", ast.BadStmt{})
-	registerType("BasicLit", ast.BasicLit{})
-	registerType("BinaryExpr", ast.BinaryExpr{})
-	registerType("BlockStmt", ast.BlockStmt{})
-	registerType("BranchStmt", ast.BranchStmt{})
-	registerType("CallExpr", ast.CallExpr{})
-	registerType("CaseClause", ast.CaseClause{})
-	registerType("ChanType", ast.ChanType{})
-	registerType("CommClause", ast.CommClause{})
-	registerType("Comment", ast.Comment{})
-	registerType("CommentGroup", ast.CommentGroup{})
-	registerType("CompositeLit", ast.CompositeLit{})
-	registerType("CommentMap", ast.CommentMap{})
-	registerType("DeclStmt", ast.DeclStmt{})
-	registerType("DeferStmt", ast.DeferStmt{})
-	registerType("Ellipsis", ast.Ellipsis{})
-	registerType("EmptyStmt", ast.EmptyStmt{})
-	registerType("ExprStmt", ast.ExprStmt{})
-	registerType("Field", ast.Field{})
-	registerType("FieldList", ast.FieldList{})
-	registerType("File", ast.File{})
-	registerType("ForStmt", ast.ForStmt{})
-	registerType("FuncDecl", ast.FuncDecl{})
-	registerType("FuncLit", ast.FuncLit{})
-	registerType("FuncType", ast.FuncType{})
-	registerType("GenDecl", ast.GenDecl{})
-	registerType("GoStmt", ast.GoStmt{})
-	registerType("Ident", ast.Ident{})
-	registerType("IfStmt", ast.IfStmt{})
-	registerType("ImportSpec", ast.ImportSpec{})
-	registerType("IncDecStmt", ast.IncDecStmt{})
-	registerType("IndexExpr", ast.IndexExpr{})
-	registerType("InterfaceType", ast.InterfaceType{})
-	registerType("KeyValueExpr", ast.KeyValueExpr{})
-	registerType("LabeledStmt", ast.LabeledStmt{})
-	registerType("MapType", ast.MapType{})
-	registerType("Object", ast.Object{})
-	registerType("Package", ast.Package{})
-	registerType("ParenExpr", ast.ParenExpr{})
-	registerType("RangeStmt", ast.RangeStmt{})
-	registerType("ReturnStmt", ast.ReturnStmt{})
-	registerType("Scope", ast.Scope{})
-	registerType("SelectorExpr", ast.SelectorExpr{})
-	registerType("SelectStmt", ast.SelectStmt{})
-	registerType("SendStmt", ast.SendStmt{})
-	registerType("SliceExpr", ast.SliceExpr{})
-	registerType("StarExpr", ast.StarExpr{})
-	registerType("StructType", ast.StructType{})
-	registerType("SwitchStmt", ast.SwitchStmt{})
-	registerType("TypeAssertExpr", ast.TypeAssertExpr{})
-	registerType("TypeSpec", ast.TypeSpec{})
-	registerType("TypeSwitchStmt", ast.TypeSwitchStmt{})
-	registerType("UnaryExpr", ast.UnaryExpr{})
-	registerType("ValueSpec", ast.ValueSpec{})
-}
-
 // FuncVisitor is a simple implementation of AST visitor that is used to post-process AST tree after the conversion
 type FuncVisitor func(node ast.Node)
 
@@ -93,12 +35,35 @@ func registerType(tp string, rt interface{}) {
 	typeNameToType[tp] = reflect.TypeOf(rt)
 }
 
-// NodeToAST uast/nodes node object and converts it to ast.Node
+// NodeToAST uast/nodes node object and converts it to ast.Node.
+// Positions are not converted, thus all token.Pos fields are left invalid (see Print and Format).
+// It panics with ConvertError if the node cannot be converted (see NodeToASTErr).
 func NodeToAST(n nodes.Node) ast.Node {
+	res, err := NodeToASTErr(n)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// NodeToASTErr is like NodeToAST, but returns ConvertError if the node cannot be converted,
+// for example if it has unknown types or fields, or values of unexpected kinds.
+func NodeToASTErr(n nodes.Node) (res ast.Node, err error) {
+	if _, ok := n.(nodes.Object); !ok {
+		return nil, &ConvertError{Reason: fmt.Sprintf("expected an object, got %s", nodes.KindOf(n))}
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			res, err = nil, newConvertError(n, r)
+		}
+	}()
 	// if we return nil pointer as interface it means that interface with nil pointer will be returned
 	// Elem() returns interface from nil from nil pointer inside interface
 	// then we cast interface to ast.Node
-	res := nodeToAST(n, NodeType).Interface().(ast.Node)
+	res, ok := nodeToAST(n, NodeType).Interface().(ast.Node)
+	if !ok {
+		return nil, &ConvertError{Type: uast.TypeOf(n), Reason: "not an AST node"}
+	}
 	// after previous casts some AST nodes type is assigned to nil
 	// thus we traverse over the AST node and change nil pointers to the pointers to empty objects
 	ast.Walk(FuncVisitor(func(node ast.Node) {
@@ -122,7 +87,20 @@ func NodeToAST(n nodes.Node) ast.Node {
 		}
 
 	}), res)
-	return res
+	return res, nil
+}
+
+// recoverObject is deferred by the conversion of each object. It turns a panic into ConvertError
+// that refers to the object, unless the panic already refers to one of the nested objects.
+func recoverObject(o nodes.Object) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if _, ok := r.(*ConvertError); ok {
+		panic(r)
+	}
+	panic(&ConvertError{Type: uast.TypeOf(o), Reason: fmt.Sprint(r), node: o})
 }
 
 func nodeToAST(n nodes.Node, t reflect.Type) reflect.Value {
@@ -135,61 +113,20 @@ func nodeToAST(n nodes.Node, t reflect.Type) reflect.Value {
 		}
 		return reflect.Zero(t)
 	case nodes.Object:
+		defer recoverObject(o)
+		if n, ok := objectToAST(o); ok {
+			return reflect.ValueOf(n)
+		}
 		// get @type from Object and get typeNameToType value from this key
 		tp, ok := typeNameToType[uast.TypeOf(o)]
 		if !ok {
-			panic("not supported: " + uast.TypeOf(o))
+			panic(fmt.Sprintf("unsupported node type %q", uast.TypeOf(o)))
 		}
 		val := reflect.New(tp).Elem()
 
 		// iterate over Object fields
 		for k, v := range o {
-			// skip system fields
-			if strings.Contains(k, "@") {
-				continue
-			}
-
-			// get structure type field descriptor
-			field, ok := tp.FieldByName(k)
-			if !ok {
-				panic(k + " field not found")
-			}
-			// if field is anonymous then we have an embedded struct then len(field.Index) >= 1
-			// as far as we explicitly know that AST does not have these structs, we can skip handling this case
-			if field.Anonymous {
-				panic(k + " is anonymous")
-			}
-
-			// recursively call nodeToAST until go type(goTypeVal) is obtained
-			desiredType := field.Type
-
-			var goTypeVal reflect.Value
-			// if we deal with token then we get token type of the value
-			if desiredType == reflect.TypeOf(token.Token(0)) {
-				goTypeVal = reflect.ValueOf(tokens[string(v.(nodes.String))])
-			} else {
-				// we need to pass the desiredType here to have a type t to pass to case nodes.Array:
-				goTypeVal = nodeToAST(v, desiredType)
-			}
-
-			// if desired type is pointer, set(returned) type should be the reference to goTypeVal
-			if desiredType.Kind() == reflect.Ptr || desiredType.Kind() == reflect.Interface {
-				isZero := goTypeVal.IsZero()
-				if isZero {
-					if v == nil {
-						continue
-					}
-					goTypeVal = reflect.New(goTypeVal.Type())
-				}
-			}
-
-			// this allows us to convert typed go types to go types
-			// Examples:
-			// 1) type Kind int -> int
-			// 2) int -> type Kind int
-			convertedVal := goTypeVal.Convert(desiredType)
-			// set the resulting value field as convertedVal
-			val.Field(field.Index[0]).Set(convertedVal)
+			setField(val, uast.TypeOf(o), k, v)
 		}
 		return val.Addr()
 	case nodes.Array:
@@ -226,15 +163,97 @@ func nodeToAST(n nodes.Node, t reflect.Type) reflect.Value {
 	}
 }
 
+// setField sets a field of the AST struct to the value converted from a native node using reflection.
+// Keys of system fields and fields set by optional analyses are ignored.
+func setField(val reflect.Value, typ, k string, v nodes.Node) {
+	// skip system fields
+	if strings.Contains(k, "@") {
+		return
+	}
+
+	// get structure type field descriptor
+	field, ok := val.Type().FieldByName(k)
+	if !ok && isAnalysisField(typ, k) {
+		return
+	} else if !ok {
+		panic(fmt.Sprintf("field %q not found", k))
+	}
+	// if field is anonymous then we have an embedded struct then len(field.Index) >= 1
+	// as far as we explicitly know that AST does not have these structs, we can skip handling this case
+	if field.Anonymous {
+		panic(fmt.Sprintf("field %q is anonymous", k))
+	}
+
+	// recursively call nodeToAST until go type(goTypeVal) is obtained
+	desiredType := field.Type
+
+	var goTypeVal reflect.Value
+	// if we deal with token then we get token type of the value
+	if desiredType == reflect.TypeOf(token.Token(0)) {
+		goTypeVal = reflect.ValueOf(tokens[string(v.(nodes.String))])
+	} else {
+		// we need to pass the desiredType here to have a type t to pass to case nodes.Array:
+		goTypeVal = nodeToAST(v, desiredType)
+	}
+
+	// if desired type is pointer, set(returned) type should be the reference to goTypeVal
+	if desiredType.Kind() == reflect.Ptr || desiredType.Kind() == reflect.Interface {
+		isZero := goTypeVal.IsZero()
+		if isZero {
+			if v == nil {
+				return
+			}
+			goTypeVal = reflect.New(goTypeVal.Type())
+		}
+	}
+
+	// this allows us to convert typed go types to go types
+	// Examples:
+	// 1) type Kind int -> int
+	// 2) int -> type Kind int
+	convertedVal := goTypeVal.Convert(desiredType)
+	// set the resulting value field as convertedVal
+	val.Field(field.Index[0]).Set(convertedVal)
+}
+
+// astNode converts a native node to an AST node.
+func astNode(v nodes.Node) ast.Node {
+	return nodeToAST(v, NodeType).Interface().(ast.Node)
+}
+
 // ValueToNode takes an AST node/value and converts it to a tree of uast types
 // like Object and List. In this case we have a full control of json encoding
 // and can annotate the tree with native AST type names.
 func ValueToNode(v interface{}, fs *token.FileSet) (nodes.Node, error) {
+	return ValueToNodeContext(context.Background(), v, fs)
+}
+
+// ValueToNodeContext is like ValueToNode, but stops the conversion with CanceledError
+// when the context is canceled or its deadline is exceeded.
+func ValueToNodeContext(ctx context.Context, v interface{}, fs *token.FileSet) (nodes.Node, error) {
 	val, ok := v.(reflect.Value)
 	if !ok {
 		val = reflect.ValueOf(v)
 	}
+	return valueToNode(ctx, val, fs, Limits{})
+}
 
+// valueToNode converts an AST value to native nodes, enforcing the limits on the number of nodes and the depth.
+func valueToNode(ctx context.Context, val reflect.Value, fs *token.FileSet, lim Limits) (nodes.Node, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, &CanceledError{Err: err}
+	}
+	c := &converter{ctx: ctx, done: ctx.Done(), fs: fs, lim: lim}
+	if val.Kind() == reflect.Ptr && val.Type().Implements(NodeType) && !val.IsNil() {
+		return c.convert(val.Interface().(ast.Node), 1)
+	}
+	return c.toNode(val)
+}
+
+// toNode converts an AST value to native nodes using reflection. It is used for values that are
+// not AST nodes, and for node types and fields that the converter does not know (see convert).
+func (c *converter) toNode(val reflect.Value) (nodes.Node, error) {
+	fs := c.fs
 	if !val.IsValid() {
 		return nil, nil
 	}
@@ -251,7 +270,7 @@ func ValueToNode(v interface{}, fs *token.FileSet) (nodes.Node, error) {
 		}
 		arr := make(nodes.Array, 0, val.Len())
 		for i := 0; i < val.Len(); i++ {
-			el, err := ValueToNode(val.Index(i), fs)
+			el, err := c.toNode(val.Index(i))
 			if err != nil {
 				return nil, err
 			}
@@ -259,6 +278,10 @@ func ValueToNode(v interface{}, fs *token.FileSet) (nodes.Node, error) {
 		}
 		return arr, nil
 	case reflect.Struct:
+		c.depth++
+		if err := c.check(c.depth); err != nil {
+			return nil, err
+		}
 		m := make(nodes.Object, t.NumField())
 		m[uast.KeyType] = nodes.String(t.Name()) // annotate nodes with type names
 		pos := make(uast.Positions)
@@ -273,7 +296,7 @@ func ValueToNode(v interface{}, fs *token.FileSet) (nodes.Node, error) {
 				// do not follow scope and object pointers - need a graph structure for it
 				continue
 			}
-			el, err := ValueToNode(fv, fs)
+			el, err := c.toNode(fv)
 			if err != nil {
 				return nil, err
 			}
@@ -282,12 +305,13 @@ func ValueToNode(v interface{}, fs *token.FileSet) (nodes.Node, error) {
 		if len(pos) != 0 {
 			m[uast.KeyPos] = pos.ToObject()
 		}
+		c.depth--
 		return m, nil
 	case reflect.Ptr, reflect.Interface:
 		if val.IsNil() {
 			return nil, nil
 		}
-		o, err := ValueToNode(val.Elem(), fs)
+		o, err := c.toNode(val.Elem())
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,2150 @@
+// Code generated by ./gen/gen.go DO NOT EDIT.
+
+package golang
+
+import (
+	"go/ast"
+	"reflect"
+
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+)
+
+func init() {
+	registerType("ArrayType", ast.ArrayType{})
+	registerType("AssignStmt", ast.AssignStmt{})
+	registerType("BadDecl", ast.BadDecl{})
+	registerType("BadExpr", ast.BadExpr{})
+	registerType("BadStmt", ast.BadStmt{})
+	registerType("BasicLit", ast.BasicLit{})
+	registerType("BinaryExpr", ast.BinaryExpr{})
+	registerType("BlockStmt", ast.BlockStmt{})
+	registerType("BranchStmt", ast.BranchStmt{})
+	registerType("CallExpr", ast.CallExpr{})
+	registerType("CaseClause", ast.CaseClause{})
+	registerType("ChanType", ast.ChanType{})
+	registerType("CommClause", ast.CommClause{})
+	registerType("Comment", ast.Comment{})
+	registerType("CommentGroup", ast.CommentGroup{})
+	registerType("CommentMap", ast.CommentMap{})
+	registerType("CompositeLit", ast.CompositeLit{})
+	registerType("DeclStmt", ast.DeclStmt{})
+	registerType("DeferStmt", ast.DeferStmt{})
+	registerType("Ellipsis", ast.Ellipsis{})
+	registerType("EmptyStmt", ast.EmptyStmt{})
+	registerType("ExprStmt", ast.ExprStmt{})
+	registerType("Field", ast.Field{})
+	registerType("FieldList", ast.FieldList{})
+	registerType("File", ast.File{})
+	registerType("ForStmt", ast.ForStmt{})
+	registerType("FuncDecl", ast.FuncDecl{})
+	registerType("FuncLit", ast.FuncLit{})
+	registerType("FuncType", ast.FuncType{})
+	registerType("GenDecl", ast.GenDecl{})
+	registerType("GoStmt", ast.GoStmt{})
+	registerType("Ident", ast.Ident{})
+	registerType("IfStmt", ast.IfStmt{})
+	registerType("ImportSpec", ast.ImportSpec{})
+	registerType("IncDecStmt", ast.IncDecStmt{})
+	registerType("IndexExpr", ast.IndexExpr{})
+	registerType("InterfaceType", ast.InterfaceType{})
+	registerType("KeyValueExpr", ast.KeyValueExpr{})
+	registerType("LabeledStmt", ast.LabeledStmt{})
+	registerType("MapType", ast.MapType{})
+	registerType("Object", ast.Object{})
+	registerType("Package", ast.Package{})
+	registerType("ParenExpr", ast.ParenExpr{})
+	registerType("RangeStmt", ast.RangeStmt{})
+	registerType("ReturnStmt", ast.ReturnStmt{})
+	registerType("Scope", ast.Scope{})
+	registerType("SelectStmt", ast.SelectStmt{})
+	registerType("SelectorExpr", ast.SelectorExpr{})
+	registerType("SendStmt", ast.SendStmt{})
+	registerType("SliceExpr", ast.SliceExpr{})
+	registerType("StarExpr", ast.StarExpr{})
+	registerType("StructType", ast.StructType{})
+	registerType("SwitchStmt", ast.SwitchStmt{})
+	registerType("TypeAssertExpr", ast.TypeAssertExpr{})
+	registerType("TypeSpec", ast.TypeSpec{})
+	registerType("TypeSwitchStmt", ast.TypeSwitchStmt{})
+	registerType("UnaryExpr", ast.UnaryExpr{})
+	registerType("ValueSpec", ast.ValueSpec{})
+}
+
+// astFields lists fields of AST node types known to the converter, in the order of declaration.
+// Fields added to go/ast later are converted via reflection (see extraFields).
+var astFields = map[string][]string{
+	"ArrayType":      {"Lbrack", "Len", "Elt"},
+	"AssignStmt":     {"Lhs", "TokPos", "Tok", "Rhs"},
+	"BadDecl":        {"From", "To"},
+	"BadExpr":        {"From", "To"},
+	"BadStmt":        {"From", "To"},
+	"BasicLit":       {"ValuePos", "Kind", "Value"},
+	"BinaryExpr":     {"X", "OpPos", "Op", "Y"},
+	"BlockStmt":      {"Lbrace", "List", "Rbrace"},
+	"BranchStmt":     {"TokPos", "Tok", "Label"},
+	"CallExpr":       {"Fun", "Lparen", "Args", "Ellipsis", "Rparen"},
+	"CaseClause":     {"Case", "List", "Colon", "Body"},
+	"ChanType":       {"Begin", "Arrow", "Dir", "Value"},
+	"CommClause":     {"Case", "Comm", "Colon", "Body"},
+	"Comment":        {"Slash", "Text"},
+	"CommentGroup":   {"List"},
+	"CompositeLit":   {"Type", "Lbrace", "Elts", "Rbrace", "Incomplete"},
+	"DeclStmt":       {"Decl"},
+	"DeferStmt":      {"Defer", "Call"},
+	"Ellipsis":       {"Ellipsis", "Elt"},
+	"EmptyStmt":      {"Semicolon", "Implicit"},
+	"ExprStmt":       {"X"},
+	"Field":          {"Doc", "Names", "Type", "Tag", "Comment"},
+	"FieldList":      {"Opening", "List", "Closing"},
+	"File":           {"Doc", "Package", "Name", "Decls", "Scope", "Imports", "Unresolved", "Comments"},
+	"ForStmt":        {"For", "Init", "Cond", "Post", "Body"},
+	"FuncDecl":       {"Doc", "Recv", "Name", "Type", "Body"},
+	"FuncLit":        {"Type", "Body"},
+	"FuncType":       {"Func", "Params", "Results"},
+	"GenDecl":        {"Doc", "TokPos", "Tok", "Lparen", "Specs", "Rparen"},
+	"GoStmt":         {"Go", "Call"},
+	"Ident":          {"NamePos", "Name", "Obj"},
+	"IfStmt":         {"If", "Init", "Cond", "Body", "Else"},
+	"ImportSpec":     {"Doc", "Name", "Path", "Comment", "EndPos"},
+	"IncDecStmt":     {"X", "TokPos", "Tok"},
+	"IndexExpr":      {"X", "Lbrack", "Index", "Rbrack"},
+	"InterfaceType":  {"Interface", "Methods", "Incomplete"},
+	"KeyValueExpr":   {"Key", "Colon", "Value"},
+	"LabeledStmt":    {"Label", "Colon", "Stmt"},
+	"MapType":        {"Map", "Key", "Value"},
+	"ParenExpr":      {"Lparen", "X", "Rparen"},
+	"RangeStmt":      {"For", "Key", "Value", "TokPos", "Tok", "X", "Body"},
+	"ReturnStmt":     {"Return", "Results"},
+	"SelectStmt":     {"Select", "Body"},
+	"SelectorExpr":   {"X", "Sel"},
+	"SendStmt":       {"Chan", "Arrow", "Value"},
+	"SliceExpr":      {"X", "Lbrack", "Low", "High", "Max", "Slice3", "Rbrack"},
+	"StarExpr":       {"Star", "X"},
+	"StructType":     {"Struct", "Fields", "Incomplete"},
+	"SwitchStmt":     {"Switch", "Init", "Tag", "Body"},
+	"TypeAssertExpr": {"X", "Lparen", "Type", "Rparen"},
+	"TypeSpec":       {"Doc", "Name", "Assign", "Type", "Comment"},
+	"TypeSwitchStmt": {"Switch", "Init", "Assign", "Body"},
+	"UnaryExpr":      {"OpPos", "Op", "X"},
+	"ValueSpec":      {"Doc", "Names", "Type", "Values", "Comment"},
+}
+
+// node converts a single AST node at a given nesting level, and queues the conversion of its children.
+// Nodes of unknown types are converted via reflection.
+func (c *converter) node(n ast.Node, depth int) (nodes.Node, error) {
+	switch n := n.(type) {
+	case *ast.ArrayType:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 4)
+		obj[uast.KeyType] = nodes.String("ArrayType")
+		pos := c.positions(n, 1)
+		pos["Lbrack"] = c.position(n.Lbrack)
+		obj[uast.KeyPos] = pos
+		c.field(obj, "Len", n.Len, depth)
+		c.field(obj, "Elt", n.Elt, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.AssignStmt:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 5)
+		obj[uast.KeyType] = nodes.String("AssignStmt")
+		pos := c.positions(n, 1)
+		pos["TokPos"] = c.position(n.TokPos)
+		obj[uast.KeyPos] = pos
+		c.exprs(obj, "Lhs", n.Lhs, depth)
+		obj["Tok"] = nodes.String(n.Tok.String())
+		c.exprs(obj, "Rhs", n.Rhs, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.BadDecl:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 2)
+		obj[uast.KeyType] = nodes.String("BadDecl")
+		pos := c.positions(n, 2)
+		pos["From"] = c.position(n.From)
+		pos["To"] = c.position(n.To)
+		obj[uast.KeyPos] = pos
+		return c.extra(obj, pos, n, depth)
+	case *ast.BadExpr:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 2)
+		obj[uast.KeyType] = nodes.String("BadExpr")
+		pos := c.positions(n, 2)
+		pos["From"] = c.position(n.From)
+		pos["To"] = c.position(n.To)
+		obj[uast.KeyPos] = pos
+		return c.extra(obj, pos, n, depth)
+	case *ast.BadStmt:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 2)
+		obj[uast.KeyType] = nodes.String("BadStmt")
+		pos := c.positions(n, 2)
+		pos["From"] = c.position(n.From)
+		pos["To"] = c.position(n.To)
+		obj[uast.KeyPos] = pos
+		return c.extra(obj, pos, n, depth)
+	case *ast.BasicLit:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 4)
+		obj[uast.KeyType] = nodes.String("BasicLit")
+		pos := c.positions(n, 1)
+		pos["ValuePos"] = c.position(n.ValuePos)
+		obj[uast.KeyPos] = pos
+		obj["Kind"] = nodes.String(n.Kind.String())
+		obj["Value"] = nodes.String(n.Value)
+		return c.extra(obj, pos, n, depth)
+	case *ast.BinaryExpr:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 5)
+		obj[uast.KeyType] = nodes.String("BinaryExpr")
+		pos := c.positions(n, 1)
+		pos["OpPos"] = c.position(n.OpPos)
+		obj[uast.KeyPos] = pos
+		c.field(obj, "X", n.X, depth)
+		obj["Op"] = nodes.String(n.Op.String())
+		c.field(obj, "Y", n.Y, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.BlockStmt:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 3)
+		obj[uast.KeyType] = nodes.String("BlockStmt")
+		pos := c.positions(n, 2)
+		pos["Lbrace"] = c.position(n.Lbrace)
+		pos["Rbrace"] = c.position(n.Rbrace)
+		obj[uast.KeyPos] = pos
+		c.stmts(obj, "List", n.List, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.BranchStmt:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 4)
+		obj[uast.KeyType] = nodes.String("BranchStmt")
+		pos := c.positions(n, 1)
+		pos["TokPos"] = c.position(n.TokPos)
+		obj[uast.KeyPos] = pos
+		obj["Tok"] = nodes.String(n.Tok.String())
+		obj["Label"] = nil
+		if n.Label != nil {
+			c.field(obj, "Label", n.Label, depth)
+		}
+		return c.extra(obj, pos, n, depth)
+	case *ast.CallExpr:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 4)
+		obj[uast.KeyType] = nodes.String("CallExpr")
+		pos := c.positions(n, 3)
+		pos["Lparen"] = c.position(n.Lparen)
+		pos["Ellipsis"] = c.position(n.Ellipsis)
+		pos["Rparen"] = c.position(n.Rparen)
+		obj[uast.KeyPos] = pos
+		c.field(obj, "Fun", n.Fun, depth)
+		c.exprs(obj, "Args", n.Args, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.CaseClause:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 4)
+		obj[uast.KeyType] = nodes.String("CaseClause")
+		pos := c.positions(n, 2)
+		pos["Case"] = c.position(n.Case)
+		pos["Colon"] = c.position(n.Colon)
+		obj[uast.KeyPos] = pos
+		c.exprs(obj, "List", n.List, depth)
+		c.stmts(obj, "Body", n.Body, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.ChanType:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 4)
+		obj[uast.KeyType] = nodes.String("ChanType")
+		pos := c.positions(n, 2)
+		pos["Begin"] = c.position(n.Begin)
+		pos["Arrow"] = c.position(n.Arrow)
+		obj[uast.KeyPos] = pos
+		obj["Dir"] = nodes.Int(n.Dir)
+		c.field(obj, "Value", n.Value, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.CommClause:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 4)
+		obj[uast.KeyType] = nodes.String("CommClause")
+		pos := c.positions(n, 2)
+		pos["Case"] = c.position(n.Case)
+		pos["Colon"] = c.position(n.Colon)
+		obj[uast.KeyPos] = pos
+		c.field(obj, "Comm", n.Comm, depth)
+		c.stmts(obj, "Body", n.Body, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.Comment:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 3)
+		obj[uast.KeyType] = nodes.String("Comment")
+		pos := c.positions(n, 1)
+		pos["Slash"] = c.position(n.Slash)
+		obj[uast.KeyPos] = pos
+		obj["Text"] = nodes.String(n.Text)
+		return c.extra(obj, pos, n, depth)
+	case *ast.CommentGroup:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 3)
+		obj[uast.KeyType] = nodes.String("CommentGroup")
+		pos := c.positions(n, 0)
+		obj[uast.KeyPos] = pos
+		c.comments(obj, "List", n.List, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.CompositeLit:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 5)
+		obj[uast.KeyType] = nodes.String("CompositeLit")
+		pos := c.positions(n, 2)
+		pos["Lbrace"] = c.position(n.Lbrace)
+		pos["Rbrace"] = c.position(n.Rbrace)
+		obj[uast.KeyPos] = pos
+		c.field(obj, "Type", n.Type, depth)
+		c.exprs(obj, "Elts", n.Elts, depth)
+		obj["Incomplete"] = nodes.Bool(n.Incomplete)
+		return c.extra(obj, pos, n, depth)
+	case *ast.DeclStmt:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 3)
+		obj[uast.KeyType] = nodes.String("DeclStmt")
+		pos := c.positions(n, 0)
+		obj[uast.KeyPos] = pos
+		c.field(obj, "Decl", n.Decl, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.DeferStmt:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 3)
+		obj[uast.KeyType] = nodes.String("DeferStmt")
+		pos := c.positions(n, 1)
+		pos["Defer"] = c.position(n.Defer)
+		obj[uast.KeyPos] = pos
+		obj["Call"] = nil
+		if n.Call != nil {
+			c.field(obj, "Call", n.Call, depth)
+		}
+		return c.extra(obj, pos, n, depth)
+	case *ast.Ellipsis:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 3)
+		obj[uast.KeyType] = nodes.String("Ellipsis")
+		pos := c.positions(n, 1)
+		pos["Ellipsis"] = c.position(n.Ellipsis)
+		obj[uast.KeyPos] = pos
+		c.field(obj, "Elt", n.Elt, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.EmptyStmt:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 3)
+		obj[uast.KeyType] = nodes.String("EmptyStmt")
+		pos := c.positions(n, 1)
+		pos["Semicolon"] = c.position(n.Semicolon)
+		obj[uast.KeyPos] = pos
+		obj["Implicit"] = nodes.Bool(n.Implicit)
+		return c.extra(obj, pos, n, depth)
+	case *ast.ExprStmt:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 3)
+		obj[uast.KeyType] = nodes.String("ExprStmt")
+		pos := c.positions(n, 0)
+		obj[uast.KeyPos] = pos
+		c.field(obj, "X", n.X, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.Field:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 7)
+		obj[uast.KeyType] = nodes.String("Field")
+		pos := c.positions(n, 0)
+		obj[uast.KeyPos] = pos
+		obj["Doc"] = nil
+		if n.Doc != nil {
+			c.field(obj, "Doc", n.Doc, depth)
+		}
+		c.idents(obj, "Names", n.Names, depth)
+		c.field(obj, "Type", n.Type, depth)
+		obj["Tag"] = nil
+		if n.Tag != nil {
+			c.field(obj, "Tag", n.Tag, depth)
+		}
+		obj["Comment"] = nil
+		if n.Comment != nil {
+			c.field(obj, "Comment", n.Comment, depth)
+		}
+		return c.extra(obj, pos, n, depth)
+	case *ast.FieldList:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 3)
+		obj[uast.KeyType] = nodes.String("FieldList")
+		pos := c.positions(n, 2)
+		pos["Opening"] = c.position(n.Opening)
+		pos["Closing"] = c.position(n.Closing)
+		obj[uast.KeyPos] = pos
+		c.fields(obj, "List", n.List, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.File:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 8)
+		obj[uast.KeyType] = nodes.String("File")
+		pos := c.positions(n, 1)
+		pos["Package"] = c.position(n.Package)
+		obj[uast.KeyPos] = pos
+		obj["Doc"] = nil
+		if n.Doc != nil {
+			c.field(obj, "Doc", n.Doc, depth)
+		}
+		obj["Name"] = nil
+		if n.Name != nil {
+			c.field(obj, "Name", n.Name, depth)
+		}
+		c.decls(obj, "Decls", n.Decls, depth)
+		c.importSpecs(obj, "Imports", n.Imports, depth)
+		c.idents(obj, "Unresolved", n.Unresolved, depth)
+		c.commentGroups(obj, "Comments", n.Comments, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.ForStmt:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 6)
+		obj[uast.KeyType] = nodes.String("ForStmt")
+		pos := c.positions(n, 1)
+		pos["For"] = c.position(n.For)
+		obj[uast.KeyPos] = pos
+		c.field(obj, "Init", n.Init, depth)
+		c.field(obj, "Cond", n.Cond, depth)
+		c.field(obj, "Post", n.Post, depth)
+		obj["Body"] = nil
+		if n.Body != nil {
+			c.field(obj, "Body", n.Body, depth)
+		}
+		return c.extra(obj, pos, n, depth)
+	case *ast.FuncDecl:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 7)
+		obj[uast.KeyType] = nodes.String("FuncDecl")
+		pos := c.positions(n, 0)
+		obj[uast.KeyPos] = pos
+		obj["Doc"] = nil
+		if n.Doc != nil {
+			c.field(obj, "Doc", n.Doc, depth)
+		}
+		obj["Recv"] = nil
+		if n.Recv != nil {
+			c.field(obj, "Recv", n.Recv, depth)
+		}
+		obj["Name"] = nil
+		if n.Name != nil {
+			c.field(obj, "Name", n.Name, depth)
+		}
+		obj["Type"] = nil
+		if n.Type != nil {
+			c.field(obj, "Type", n.Type, depth)
+		}
+		obj["Body"] = nil
+		if n.Body != nil {
+			c.field(obj, "Body", n.Body, depth)
+		}
+		return c.extra(obj, pos, n, depth)
+	case *ast.FuncLit:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 4)
+		obj[uast.KeyType] = nodes.String("FuncLit")
+		pos := c.positions(n, 0)
+		obj[uast.KeyPos] = pos
+		obj["Type"] = nil
+		if n.Type != nil {
+			c.field(obj, "Type", n.Type, depth)
+		}
+		obj["Body"] = nil
+		if n.Body != nil {
+			c.field(obj, "Body", n.Body, depth)
+		}
+		return c.extra(obj, pos, n, depth)
+	case *ast.FuncType:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 4)
+		obj[uast.KeyType] = nodes.String("FuncType")
+		pos := c.positions(n, 1)
+		pos["Func"] = c.position(n.Func)
+		obj[uast.KeyPos] = pos
+		obj["Params"] = nil
+		if n.Params != nil {
+			c.field(obj, "Params", n.Params, depth)
+		}
+		obj["Results"] = nil
+		if n.Results != nil {
+			c.field(obj, "Results", n.Results, depth)
+		}
+		return c.extra(obj, pos, n, depth)
+	case *ast.GenDecl:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 5)
+		obj[uast.KeyType] = nodes.String("GenDecl")
+		pos := c.positions(n, 3)
+		pos["TokPos"] = c.position(n.TokPos)
+		pos["Lparen"] = c.position(n.Lparen)
+		pos["Rparen"] = c.position(n.Rparen)
+		obj[uast.KeyPos] = pos
+		obj["Doc"] = nil
+		if n.Doc != nil {
+			c.field(obj, "Doc", n.Doc, depth)
+		}
+		obj["Tok"] = nodes.String(n.Tok.String())
+		c.specs(obj, "Specs", n.Specs, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.GoStmt:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 3)
+		obj[uast.KeyType] = nodes.String("GoStmt")
+		pos := c.positions(n, 1)
+		pos["Go"] = c.position(n.Go)
+		obj[uast.KeyPos] = pos
+		obj["Call"] = nil
+		if n.Call != nil {
+			c.field(obj, "Call", n.Call, depth)
+		}
+		return c.extra(obj, pos, n, depth)
+	case *ast.Ident:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 3)
+		obj[uast.KeyType] = nodes.String("Ident")
+		pos := c.positions(n, 1)
+		pos["NamePos"] = c.position(n.NamePos)
+		obj[uast.KeyPos] = pos
+		obj["Name"] = nodes.String(n.Name)
+		return c.extra(obj, pos, n, depth)
+	case *ast.IfStmt:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 6)
+		obj[uast.KeyType] = nodes.String("IfStmt")
+		pos := c.positions(n, 1)
+		pos["If"] = c.position(n.If)
+		obj[uast.KeyPos] = pos
+		c.field(obj, "Init", n.Init, depth)
+		c.field(obj, "Cond", n.Cond, depth)
+		obj["Body"] = nil
+		if n.Body != nil {
+			c.field(obj, "Body", n.Body, depth)
+		}
+		c.field(obj, "Else", n.Else, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.ImportSpec:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 6)
+		obj[uast.KeyType] = nodes.String("ImportSpec")
+		pos := c.positions(n, 1)
+		pos["EndPos"] = c.position(n.EndPos)
+		obj[uast.KeyPos] = pos
+		obj["Doc"] = nil
+		if n.Doc != nil {
+			c.field(obj, "Doc", n.Doc, depth)
+		}
+		obj["Name"] = nil
+		if n.Name != nil {
+			c.field(obj, "Name", n.Name, depth)
+		}
+		obj["Path"] = nil
+		if n.Path != nil {
+			c.field(obj, "Path", n.Path, depth)
+		}
+		obj["Comment"] = nil
+		if n.Comment != nil {
+			c.field(obj, "Comment", n.Comment, depth)
+		}
+		return c.extra(obj, pos, n, depth)
+	case *ast.IncDecStmt:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 4)
+		obj[uast.KeyType] = nodes.String("IncDecStmt")
+		pos := c.positions(n, 1)
+		pos["TokPos"] = c.position(n.TokPos)
+		obj[uast.KeyPos] = pos
+		c.field(obj, "X", n.X, depth)
+		obj["Tok"] = nodes.String(n.Tok.String())
+		return c.extra(obj, pos, n, depth)
+	case *ast.IndexExpr:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 4)
+		obj[uast.KeyType] = nodes.String("IndexExpr")
+		pos := c.positions(n, 2)
+		pos["Lbrack"] = c.position(n.Lbrack)
+		pos["Rbrack"] = c.position(n.Rbrack)
+		obj[uast.KeyPos] = pos
+		c.field(obj, "X", n.X, depth)
+		c.field(obj, "Index", n.Index, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.InterfaceType:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 4)
+		obj[uast.KeyType] = nodes.String("InterfaceType")
+		pos := c.positions(n, 1)
+		pos["Interface"] = c.position(n.Interface)
+		obj[uast.KeyPos] = pos
+		obj["Methods"] = nil
+		if n.Methods != nil {
+			c.field(obj, "Methods", n.Methods, depth)
+		}
+		obj["Incomplete"] = nodes.Bool(n.Incomplete)
+		return c.extra(obj, pos, n, depth)
+	case *ast.KeyValueExpr:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 4)
+		obj[uast.KeyType] = nodes.String("KeyValueExpr")
+		pos := c.positions(n, 1)
+		pos["Colon"] = c.position(n.Colon)
+		obj[uast.KeyPos] = pos
+		c.field(obj, "Key", n.Key, depth)
+		c.field(obj, "Value", n.Value, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.LabeledStmt:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 4)
+		obj[uast.KeyType] = nodes.String("LabeledStmt")
+		pos := c.positions(n, 1)
+		pos["Colon"] = c.position(n.Colon)
+		obj[uast.KeyPos] = pos
+		obj["Label"] = nil
+		if n.Label != nil {
+			c.field(obj, "Label", n.Label, depth)
+		}
+		c.field(obj, "Stmt", n.Stmt, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.MapType:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 4)
+		obj[uast.KeyType] = nodes.String("MapType")
+		pos := c.positions(n, 1)
+		pos["Map"] = c.position(n.Map)
+		obj[uast.KeyPos] = pos
+		c.field(obj, "Key", n.Key, depth)
+		c.field(obj, "Value", n.Value, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.ParenExpr:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 3)
+		obj[uast.KeyType] = nodes.String("ParenExpr")
+		pos := c.positions(n, 2)
+		pos["Lparen"] = c.position(n.Lparen)
+		pos["Rparen"] = c.position(n.Rparen)
+		obj[uast.KeyPos] = pos
+		c.field(obj, "X", n.X, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.RangeStmt:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 7)
+		obj[uast.KeyType] = nodes.String("RangeStmt")
+		pos := c.positions(n, 2)
+		pos["For"] = c.position(n.For)
+		pos["TokPos"] = c.position(n.TokPos)
+		obj[uast.KeyPos] = pos
+		c.field(obj, "Key", n.Key, depth)
+		c.field(obj, "Value", n.Value, depth)
+		obj["Tok"] = nodes.String(n.Tok.String())
+		c.field(obj, "X", n.X, depth)
+		obj["Body"] = nil
+		if n.Body != nil {
+			c.field(obj, "Body", n.Body, depth)
+		}
+		return c.extra(obj, pos, n, depth)
+	case *ast.ReturnStmt:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 3)
+		obj[uast.KeyType] = nodes.String("ReturnStmt")
+		pos := c.positions(n, 1)
+		pos["Return"] = c.position(n.Return)
+		obj[uast.KeyPos] = pos
+		c.exprs(obj, "Results", n.Results, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.SelectStmt:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 3)
+		obj[uast.KeyType] = nodes.String("SelectStmt")
+		pos := c.positions(n, 1)
+		pos["Select"] = c.position(n.Select)
+		obj[uast.KeyPos] = pos
+		obj["Body"] = nil
+		if n.Body != nil {
+			c.field(obj, "Body", n.Body, depth)
+		}
+		return c.extra(obj, pos, n, depth)
+	case *ast.SelectorExpr:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 4)
+		obj[uast.KeyType] = nodes.String("SelectorExpr")
+		pos := c.positions(n, 0)
+		obj[uast.KeyPos] = pos
+		c.field(obj, "X", n.X, depth)
+		obj["Sel"] = nil
+		if n.Sel != nil {
+			c.field(obj, "Sel", n.Sel, depth)
+		}
+		return c.extra(obj, pos, n, depth)
+	case *ast.SendStmt:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 4)
+		obj[uast.KeyType] = nodes.String("SendStmt")
+		pos := c.positions(n, 1)
+		pos["Arrow"] = c.position(n.Arrow)
+		obj[uast.KeyPos] = pos
+		c.field(obj, "Chan", n.Chan, depth)
+		c.field(obj, "Value", n.Value, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.SliceExpr:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 7)
+		obj[uast.KeyType] = nodes.String("SliceExpr")
+		pos := c.positions(n, 2)
+		pos["Lbrack"] = c.position(n.Lbrack)
+		pos["Rbrack"] = c.position(n.Rbrack)
+		obj[uast.KeyPos] = pos
+		c.field(obj, "X", n.X, depth)
+		c.field(obj, "Low", n.Low, depth)
+		c.field(obj, "High", n.High, depth)
+		c.field(obj, "Max", n.Max, depth)
+		obj["Slice3"] = nodes.Bool(n.Slice3)
+		return c.extra(obj, pos, n, depth)
+	case *ast.StarExpr:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 3)
+		obj[uast.KeyType] = nodes.String("StarExpr")
+		pos := c.positions(n, 1)
+		pos["Star"] = c.position(n.Star)
+		obj[uast.KeyPos] = pos
+		c.field(obj, "X", n.X, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.StructType:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 4)
+		obj[uast.KeyType] = nodes.String("StructType")
+		pos := c.positions(n, 1)
+		pos["Struct"] = c.position(n.Struct)
+		obj[uast.KeyPos] = pos
+		obj["Fields"] = nil
+		if n.Fields != nil {
+			c.field(obj, "Fields", n.Fields, depth)
+		}
+		obj["Incomplete"] = nodes.Bool(n.Incomplete)
+		return c.extra(obj, pos, n, depth)
+	case *ast.SwitchStmt:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 5)
+		obj[uast.KeyType] = nodes.String("SwitchStmt")
+		pos := c.positions(n, 1)
+		pos["Switch"] = c.position(n.Switch)
+		obj[uast.KeyPos] = pos
+		c.field(obj, "Init", n.Init, depth)
+		c.field(obj, "Tag", n.Tag, depth)
+		obj["Body"] = nil
+		if n.Body != nil {
+			c.field(obj, "Body", n.Body, depth)
+		}
+		return c.extra(obj, pos, n, depth)
+	case *ast.TypeAssertExpr:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 4)
+		obj[uast.KeyType] = nodes.String("TypeAssertExpr")
+		pos := c.positions(n, 2)
+		pos["Lparen"] = c.position(n.Lparen)
+		pos["Rparen"] = c.position(n.Rparen)
+		obj[uast.KeyPos] = pos
+		c.field(obj, "X", n.X, depth)
+		c.field(obj, "Type", n.Type, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.TypeSpec:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 6)
+		obj[uast.KeyType] = nodes.String("TypeSpec")
+		pos := c.positions(n, 1)
+		pos["Assign"] = c.position(n.Assign)
+		obj[uast.KeyPos] = pos
+		obj["Doc"] = nil
+		if n.Doc != nil {
+			c.field(obj, "Doc", n.Doc, depth)
+		}
+		obj["Name"] = nil
+		if n.Name != nil {
+			c.field(obj, "Name", n.Name, depth)
+		}
+		c.field(obj, "Type", n.Type, depth)
+		obj["Comment"] = nil
+		if n.Comment != nil {
+			c.field(obj, "Comment", n.Comment, depth)
+		}
+		return c.extra(obj, pos, n, depth)
+	case *ast.TypeSwitchStmt:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 5)
+		obj[uast.KeyType] = nodes.String("TypeSwitchStmt")
+		pos := c.positions(n, 1)
+		pos["Switch"] = c.position(n.Switch)
+		obj[uast.KeyPos] = pos
+		c.field(obj, "Init", n.Init, depth)
+		c.field(obj, "Assign", n.Assign, depth)
+		obj["Body"] = nil
+		if n.Body != nil {
+			c.field(obj, "Body", n.Body, depth)
+		}
+		return c.extra(obj, pos, n, depth)
+	case *ast.UnaryExpr:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 4)
+		obj[uast.KeyType] = nodes.String("UnaryExpr")
+		pos := c.positions(n, 1)
+		pos["OpPos"] = c.position(n.OpPos)
+		obj[uast.KeyPos] = pos
+		obj["Op"] = nodes.String(n.Op.String())
+		c.field(obj, "X", n.X, depth)
+		return c.extra(obj, pos, n, depth)
+	case *ast.ValueSpec:
+		if n == nil {
+			return nil, nil
+		}
+		if err := c.check(depth); err != nil {
+			return nil, err
+		}
+		obj := make(nodes.Object, 7)
+		obj[uast.KeyType] = nodes.String("ValueSpec")
+		pos := c.positions(n, 0)
+		obj[uast.KeyPos] = pos
+		obj["Doc"] = nil
+		if n.Doc != nil {
+			c.field(obj, "Doc", n.Doc, depth)
+		}
+		c.idents(obj, "Names", n.Names, depth)
+		c.field(obj, "Type", n.Type, depth)
+		c.exprs(obj, "Values", n.Values, depth)
+		obj["Comment"] = nil
+		if n.Comment != nil {
+			c.field(obj, "Comment", n.Comment, depth)
+		}
+		return c.extra(obj, pos, n, depth)
+	}
+	c.depth = depth - 1
+	return c.toNode(reflect.ValueOf(n))
+}
+
+// objectToAST converts a native node to an AST node without reflection. It returns false for unknown node types.
+// Fields that are not known to the converter are set via reflection.
+func objectToAST(o nodes.Object) (ast.Node, bool) {
+	typ := uast.TypeOf(o)
+	switch typ {
+	case "ArrayType":
+		n := &ast.ArrayType{}
+		for k, v := range o {
+			switch k {
+			case "Len":
+				n.Len = toExpr(v)
+			case "Elt":
+				n.Elt = toExpr(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "AssignStmt":
+		n := &ast.AssignStmt{}
+		for k, v := range o {
+			switch k {
+			case "Lhs":
+				n.Lhs = toExprs(v)
+			case "Tok":
+				n.Tok = tokens[string(v.(nodes.String))]
+			case "Rhs":
+				n.Rhs = toExprs(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "BadDecl":
+		n := &ast.BadDecl{}
+		for k, v := range o {
+			switch k {
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "BadExpr":
+		n := &ast.BadExpr{}
+		for k, v := range o {
+			switch k {
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "BadStmt":
+		n := &ast.BadStmt{}
+		for k, v := range o {
+			switch k {
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "BasicLit":
+		n := &ast.BasicLit{}
+		for k, v := range o {
+			switch k {
+			case "Kind":
+				n.Kind = tokens[string(v.(nodes.String))]
+			case "Value":
+				n.Value = string(v.(nodes.String))
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "BinaryExpr":
+		n := &ast.BinaryExpr{}
+		for k, v := range o {
+			switch k {
+			case "X":
+				n.X = toExpr(v)
+			case "Op":
+				n.Op = tokens[string(v.(nodes.String))]
+			case "Y":
+				n.Y = toExpr(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "BlockStmt":
+		n := &ast.BlockStmt{}
+		for k, v := range o {
+			switch k {
+			case "List":
+				n.List = toStmts(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "BranchStmt":
+		n := &ast.BranchStmt{}
+		for k, v := range o {
+			switch k {
+			case "Tok":
+				n.Tok = tokens[string(v.(nodes.String))]
+			case "Label":
+				n.Label = toIdent(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "CallExpr":
+		n := &ast.CallExpr{}
+		for k, v := range o {
+			switch k {
+			case "Fun":
+				n.Fun = toExpr(v)
+			case "Args":
+				n.Args = toExprs(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "CaseClause":
+		n := &ast.CaseClause{}
+		for k, v := range o {
+			switch k {
+			case "List":
+				n.List = toExprs(v)
+			case "Body":
+				n.Body = toStmts(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "ChanType":
+		n := &ast.ChanType{}
+		for k, v := range o {
+			switch k {
+			case "Dir":
+				n.Dir = ast.ChanDir(v.(nodes.Int))
+			case "Value":
+				n.Value = toExpr(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "CommClause":
+		n := &ast.CommClause{}
+		for k, v := range o {
+			switch k {
+			case "Comm":
+				n.Comm = toStmt(v)
+			case "Body":
+				n.Body = toStmts(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "Comment":
+		n := &ast.Comment{}
+		for k, v := range o {
+			switch k {
+			case "Text":
+				n.Text = string(v.(nodes.String))
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "CommentGroup":
+		n := &ast.CommentGroup{}
+		for k, v := range o {
+			switch k {
+			case "List":
+				n.List = toComments(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "CompositeLit":
+		n := &ast.CompositeLit{}
+		for k, v := range o {
+			switch k {
+			case "Type":
+				n.Type = toExpr(v)
+			case "Elts":
+				n.Elts = toExprs(v)
+			case "Incomplete":
+				n.Incomplete = bool(v.(nodes.Bool))
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "DeclStmt":
+		n := &ast.DeclStmt{}
+		for k, v := range o {
+			switch k {
+			case "Decl":
+				n.Decl = toDecl(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "DeferStmt":
+		n := &ast.DeferStmt{}
+		for k, v := range o {
+			switch k {
+			case "Call":
+				n.Call = toCallExpr(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "Ellipsis":
+		n := &ast.Ellipsis{}
+		for k, v := range o {
+			switch k {
+			case "Elt":
+				n.Elt = toExpr(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "EmptyStmt":
+		n := &ast.EmptyStmt{}
+		for k, v := range o {
+			switch k {
+			case "Implicit":
+				n.Implicit = bool(v.(nodes.Bool))
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "ExprStmt":
+		n := &ast.ExprStmt{}
+		for k, v := range o {
+			switch k {
+			case "X":
+				n.X = toExpr(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "Field":
+		n := &ast.Field{}
+		for k, v := range o {
+			switch k {
+			case "Doc":
+				n.Doc = toCommentGroup(v)
+			case "Names":
+				n.Names = toIdents(v)
+			case "Type":
+				n.Type = toExpr(v)
+			case "Tag":
+				n.Tag = toBasicLit(v)
+			case "Comment":
+				n.Comment = toCommentGroup(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "FieldList":
+		n := &ast.FieldList{}
+		for k, v := range o {
+			switch k {
+			case "List":
+				n.List = toFields(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "File":
+		n := &ast.File{}
+		for k, v := range o {
+			switch k {
+			case "Doc":
+				n.Doc = toCommentGroup(v)
+			case "Name":
+				n.Name = toIdent(v)
+			case "Decls":
+				n.Decls = toDecls(v)
+			case "Imports":
+				n.Imports = toImportSpecs(v)
+			case "Unresolved":
+				n.Unresolved = toIdents(v)
+			case "Comments":
+				n.Comments = toCommentGroups(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "ForStmt":
+		n := &ast.ForStmt{}
+		for k, v := range o {
+			switch k {
+			case "Init":
+				n.Init = toStmt(v)
+			case "Cond":
+				n.Cond = toExpr(v)
+			case "Post":
+				n.Post = toStmt(v)
+			case "Body":
+				n.Body = toBlockStmt(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "FuncDecl":
+		n := &ast.FuncDecl{}
+		for k, v := range o {
+			switch k {
+			case "Doc":
+				n.Doc = toCommentGroup(v)
+			case "Recv":
+				n.Recv = toFieldList(v)
+			case "Name":
+				n.Name = toIdent(v)
+			case "Type":
+				n.Type = toFuncType(v)
+			case "Body":
+				n.Body = toBlockStmt(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "FuncLit":
+		n := &ast.FuncLit{}
+		for k, v := range o {
+			switch k {
+			case "Type":
+				n.Type = toFuncType(v)
+			case "Body":
+				n.Body = toBlockStmt(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "FuncType":
+		n := &ast.FuncType{}
+		for k, v := range o {
+			switch k {
+			case "Params":
+				n.Params = toFieldList(v)
+			case "Results":
+				n.Results = toFieldList(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "GenDecl":
+		n := &ast.GenDecl{}
+		for k, v := range o {
+			switch k {
+			case "Doc":
+				n.Doc = toCommentGroup(v)
+			case "Tok":
+				n.Tok = tokens[string(v.(nodes.String))]
+			case "Specs":
+				n.Specs = toSpecs(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "GoStmt":
+		n := &ast.GoStmt{}
+		for k, v := range o {
+			switch k {
+			case "Call":
+				n.Call = toCallExpr(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "Ident":
+		n := &ast.Ident{}
+		for k, v := range o {
+			switch k {
+			case "Name":
+				n.Name = string(v.(nodes.String))
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "IfStmt":
+		n := &ast.IfStmt{}
+		for k, v := range o {
+			switch k {
+			case "Init":
+				n.Init = toStmt(v)
+			case "Cond":
+				n.Cond = toExpr(v)
+			case "Body":
+				n.Body = toBlockStmt(v)
+			case "Else":
+				n.Else = toStmt(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "ImportSpec":
+		n := &ast.ImportSpec{}
+		for k, v := range o {
+			switch k {
+			case "Doc":
+				n.Doc = toCommentGroup(v)
+			case "Name":
+				n.Name = toIdent(v)
+			case "Path":
+				n.Path = toBasicLit(v)
+			case "Comment":
+				n.Comment = toCommentGroup(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "IncDecStmt":
+		n := &ast.IncDecStmt{}
+		for k, v := range o {
+			switch k {
+			case "X":
+				n.X = toExpr(v)
+			case "Tok":
+				n.Tok = tokens[string(v.(nodes.String))]
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "IndexExpr":
+		n := &ast.IndexExpr{}
+		for k, v := range o {
+			switch k {
+			case "X":
+				n.X = toExpr(v)
+			case "Index":
+				n.Index = toExpr(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "InterfaceType":
+		n := &ast.InterfaceType{}
+		for k, v := range o {
+			switch k {
+			case "Methods":
+				n.Methods = toFieldList(v)
+			case "Incomplete":
+				n.Incomplete = bool(v.(nodes.Bool))
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "KeyValueExpr":
+		n := &ast.KeyValueExpr{}
+		for k, v := range o {
+			switch k {
+			case "Key":
+				n.Key = toExpr(v)
+			case "Value":
+				n.Value = toExpr(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "LabeledStmt":
+		n := &ast.LabeledStmt{}
+		for k, v := range o {
+			switch k {
+			case "Label":
+				n.Label = toIdent(v)
+			case "Stmt":
+				n.Stmt = toStmt(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "MapType":
+		n := &ast.MapType{}
+		for k, v := range o {
+			switch k {
+			case "Key":
+				n.Key = toExpr(v)
+			case "Value":
+				n.Value = toExpr(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "ParenExpr":
+		n := &ast.ParenExpr{}
+		for k, v := range o {
+			switch k {
+			case "X":
+				n.X = toExpr(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "RangeStmt":
+		n := &ast.RangeStmt{}
+		for k, v := range o {
+			switch k {
+			case "Key":
+				n.Key = toExpr(v)
+			case "Value":
+				n.Value = toExpr(v)
+			case "Tok":
+				n.Tok = tokens[string(v.(nodes.String))]
+			case "X":
+				n.X = toExpr(v)
+			case "Body":
+				n.Body = toBlockStmt(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "ReturnStmt":
+		n := &ast.ReturnStmt{}
+		for k, v := range o {
+			switch k {
+			case "Results":
+				n.Results = toExprs(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "SelectStmt":
+		n := &ast.SelectStmt{}
+		for k, v := range o {
+			switch k {
+			case "Body":
+				n.Body = toBlockStmt(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "SelectorExpr":
+		n := &ast.SelectorExpr{}
+		for k, v := range o {
+			switch k {
+			case "X":
+				n.X = toExpr(v)
+			case "Sel":
+				n.Sel = toIdent(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "SendStmt":
+		n := &ast.SendStmt{}
+		for k, v := range o {
+			switch k {
+			case "Chan":
+				n.Chan = toExpr(v)
+			case "Value":
+				n.Value = toExpr(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "SliceExpr":
+		n := &ast.SliceExpr{}
+		for k, v := range o {
+			switch k {
+			case "X":
+				n.X = toExpr(v)
+			case "Low":
+				n.Low = toExpr(v)
+			case "High":
+				n.High = toExpr(v)
+			case "Max":
+				n.Max = toExpr(v)
+			case "Slice3":
+				n.Slice3 = bool(v.(nodes.Bool))
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "StarExpr":
+		n := &ast.StarExpr{}
+		for k, v := range o {
+			switch k {
+			case "X":
+				n.X = toExpr(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "StructType":
+		n := &ast.StructType{}
+		for k, v := range o {
+			switch k {
+			case "Fields":
+				n.Fields = toFieldList(v)
+			case "Incomplete":
+				n.Incomplete = bool(v.(nodes.Bool))
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "SwitchStmt":
+		n := &ast.SwitchStmt{}
+		for k, v := range o {
+			switch k {
+			case "Init":
+				n.Init = toStmt(v)
+			case "Tag":
+				n.Tag = toExpr(v)
+			case "Body":
+				n.Body = toBlockStmt(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "TypeAssertExpr":
+		n := &ast.TypeAssertExpr{}
+		for k, v := range o {
+			switch k {
+			case "X":
+				n.X = toExpr(v)
+			case "Type":
+				n.Type = toExpr(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "TypeSpec":
+		n := &ast.TypeSpec{}
+		for k, v := range o {
+			switch k {
+			case "Doc":
+				n.Doc = toCommentGroup(v)
+			case "Name":
+				n.Name = toIdent(v)
+			case "Type":
+				n.Type = toExpr(v)
+			case "Comment":
+				n.Comment = toCommentGroup(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "TypeSwitchStmt":
+		n := &ast.TypeSwitchStmt{}
+		for k, v := range o {
+			switch k {
+			case "Init":
+				n.Init = toStmt(v)
+			case "Assign":
+				n.Assign = toStmt(v)
+			case "Body":
+				n.Body = toBlockStmt(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "UnaryExpr":
+		n := &ast.UnaryExpr{}
+		for k, v := range o {
+			switch k {
+			case "Op":
+				n.Op = tokens[string(v.(nodes.String))]
+			case "X":
+				n.X = toExpr(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	case "ValueSpec":
+		n := &ast.ValueSpec{}
+		for k, v := range o {
+			switch k {
+			case "Doc":
+				n.Doc = toCommentGroup(v)
+			case "Names":
+				n.Names = toIdents(v)
+			case "Type":
+				n.Type = toExpr(v)
+			case "Values":
+				n.Values = toExprs(v)
+			case "Comment":
+				n.Comment = toCommentGroup(v)
+			default:
+				setField(reflect.ValueOf(n).Elem(), typ, k, v)
+			}
+		}
+		return n, true
+	}
+	return nil, false
+}
+
+func (c *converter) commentGroups(obj nodes.Object, key string, list []*ast.CommentGroup, depth int) {
+	if len(list) == 0 {
+		obj[key] = nil
+		return
+	}
+	arr := make(nodes.Array, len(list))
+	for i, n := range list {
+		if n != nil {
+			c.elem(arr, i, n, depth)
+		}
+	}
+	obj[key] = arr
+}
+
+func (c *converter) comments(obj nodes.Object, key string, list []*ast.Comment, depth int) {
+	if len(list) == 0 {
+		obj[key] = nil
+		return
+	}
+	arr := make(nodes.Array, len(list))
+	for i, n := range list {
+		if n != nil {
+			c.elem(arr, i, n, depth)
+		}
+	}
+	obj[key] = arr
+}
+
+func (c *converter) decls(obj nodes.Object, key string, list []ast.Decl, depth int) {
+	if len(list) == 0 {
+		obj[key] = nil
+		return
+	}
+	arr := make(nodes.Array, len(list))
+	for i, n := range list {
+		if n != nil {
+			c.elem(arr, i, n, depth)
+		}
+	}
+	obj[key] = arr
+}
+
+func (c *converter) exprs(obj nodes.Object, key string, list []ast.Expr, depth int) {
+	if len(list) == 0 {
+		obj[key] = nil
+		return
+	}
+	arr := make(nodes.Array, len(list))
+	for i, n := range list {
+		if n != nil {
+			c.elem(arr, i, n, depth)
+		}
+	}
+	obj[key] = arr
+}
+
+func (c *converter) fields(obj nodes.Object, key string, list []*ast.Field, depth int) {
+	if len(list) == 0 {
+		obj[key] = nil
+		return
+	}
+	arr := make(nodes.Array, len(list))
+	for i, n := range list {
+		if n != nil {
+			c.elem(arr, i, n, depth)
+		}
+	}
+	obj[key] = arr
+}
+
+func (c *converter) idents(obj nodes.Object, key string, list []*ast.Ident, depth int) {
+	if len(list) == 0 {
+		obj[key] = nil
+		return
+	}
+	arr := make(nodes.Array, len(list))
+	for i, n := range list {
+		if n != nil {
+			c.elem(arr, i, n, depth)
+		}
+	}
+	obj[key] = arr
+}
+
+func (c *converter) importSpecs(obj nodes.Object, key string, list []*ast.ImportSpec, depth int) {
+	if len(list) == 0 {
+		obj[key] = nil
+		return
+	}
+	arr := make(nodes.Array, len(list))
+	for i, n := range list {
+		if n != nil {
+			c.elem(arr, i, n, depth)
+		}
+	}
+	obj[key] = arr
+}
+
+func (c *converter) specs(obj nodes.Object, key string, list []ast.Spec, depth int) {
+	if len(list) == 0 {
+		obj[key] = nil
+		return
+	}
+	arr := make(nodes.Array, len(list))
+	for i, n := range list {
+		if n != nil {
+			c.elem(arr, i, n, depth)
+		}
+	}
+	obj[key] = arr
+}
+
+func (c *converter) stmts(obj nodes.Object, key string, list []ast.Stmt, depth int) {
+	if len(list) == 0 {
+		obj[key] = nil
+		return
+	}
+	arr := make(nodes.Array, len(list))
+	for i, n := range list {
+		if n != nil {
+			c.elem(arr, i, n, depth)
+		}
+	}
+	obj[key] = arr
+}
+
+func toBasicLit(v nodes.Node) *ast.BasicLit {
+	if v == nil {
+		return nil
+	}
+	return astNode(v).(*ast.BasicLit)
+}
+
+func toBlockStmt(v nodes.Node) *ast.BlockStmt {
+	if v == nil {
+		return nil
+	}
+	return astNode(v).(*ast.BlockStmt)
+}
+
+func toCallExpr(v nodes.Node) *ast.CallExpr {
+	if v == nil {
+		return nil
+	}
+	return astNode(v).(*ast.CallExpr)
+}
+
+func toComment(v nodes.Node) *ast.Comment {
+	if v == nil {
+		return nil
+	}
+	return astNode(v).(*ast.Comment)
+}
+
+func toCommentGroup(v nodes.Node) *ast.CommentGroup {
+	if v == nil {
+		return nil
+	}
+	return astNode(v).(*ast.CommentGroup)
+}
+
+func toCommentGroups(v nodes.Node) []*ast.CommentGroup {
+	if v == nil {
+		return nil
+	}
+	arr := v.(nodes.Array)
+	list := make([]*ast.CommentGroup, len(arr))
+	for i, e := range arr {
+		list[i] = toCommentGroup(e)
+	}
+	return list
+}
+
+func toComments(v nodes.Node) []*ast.Comment {
+	if v == nil {
+		return nil
+	}
+	arr := v.(nodes.Array)
+	list := make([]*ast.Comment, len(arr))
+	for i, e := range arr {
+		list[i] = toComment(e)
+	}
+	return list
+}
+
+func toDecl(v nodes.Node) ast.Decl {
+	if v == nil {
+		return nil
+	}
+	return astNode(v).(ast.Decl)
+}
+
+func toDecls(v nodes.Node) []ast.Decl {
+	if v == nil {
+		return nil
+	}
+	arr := v.(nodes.Array)
+	list := make([]ast.Decl, len(arr))
+	for i, e := range arr {
+		list[i] = toDecl(e)
+	}
+	return list
+}
+
+func toExpr(v nodes.Node) ast.Expr {
+	if v == nil {
+		return nil
+	}
+	return astNode(v).(ast.Expr)
+}
+
+func toExprs(v nodes.Node) []ast.Expr {
+	if v == nil {
+		return nil
+	}
+	arr := v.(nodes.Array)
+	list := make([]ast.Expr, len(arr))
+	for i, e := range arr {
+		list[i] = toExpr(e)
+	}
+	return list
+}
+
+func toField(v nodes.Node) *ast.Field {
+	if v == nil {
+		return nil
+	}
+	return astNode(v).(*ast.Field)
+}
+
+func toFieldList(v nodes.Node) *ast.FieldList {
+	if v == nil {
+		return nil
+	}
+	return astNode(v).(*ast.FieldList)
+}
+
+func toFields(v nodes.Node) []*ast.Field {
+	if v == nil {
+		return nil
+	}
+	arr := v.(nodes.Array)
+	list := make([]*ast.Field, len(arr))
+	for i, e := range arr {
+		list[i] = toField(e)
+	}
+	return list
+}
+
+func toFuncType(v nodes.Node) *ast.FuncType {
+	if v == nil {
+		return &ast.FuncType{}
+	}
+	return astNode(v).(*ast.FuncType)
+}
+
+func toIdent(v nodes.Node) *ast.Ident {
+	if v == nil {
+		return nil
+	}
+	return astNode(v).(*ast.Ident)
+}
+
+func toIdents(v nodes.Node) []*ast.Ident {
+	if v == nil {
+		return nil
+	}
+	arr := v.(nodes.Array)
+	list := make([]*ast.Ident, len(arr))
+	for i, e := range arr {
+		list[i] = toIdent(e)
+	}
+	return list
+}
+
+func toImportSpec(v nodes.Node) *ast.ImportSpec {
+	if v == nil {
+		return nil
+	}
+	return astNode(v).(*ast.ImportSpec)
+}
+
+func toImportSpecs(v nodes.Node) []*ast.ImportSpec {
+	if v == nil {
+		return nil
+	}
+	arr := v.(nodes.Array)
+	list := make([]*ast.ImportSpec, len(arr))
+	for i, e := range arr {
+		list[i] = toImportSpec(e)
+	}
+	return list
+}
+
+func toSpec(v nodes.Node) ast.Spec {
+	if v == nil {
+		return nil
+	}
+	return astNode(v).(ast.Spec)
+}
+
+func toSpecs(v nodes.Node) []ast.Spec {
+	if v == nil {
+		return nil
+	}
+	arr := v.(nodes.Array)
+	list := make([]ast.Spec, len(arr))
+	for i, e := range arr {
+		list[i] = toSpec(e)
+	}
+	return list
+}
+
+func toStmt(v nodes.Node) ast.Stmt {
+	if v == nil {
+		return nil
+	}
+	return astNode(v).(ast.Stmt)
+}
+
+func toStmts(v nodes.Node) []ast.Stmt {
+	if v == nil {
+		return nil
+	}
+	arr := v.(nodes.Array)
+	list := make([]ast.Stmt, len(arr))
+	for i, e := range arr {
+		list[i] = toStmt(e)
+	}
+	return list
+}
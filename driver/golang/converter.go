@@ -0,0 +1,179 @@
+package golang
+
+import (
+	"context"
+	"go/ast"
+	"go/token"
+	"reflect"
+
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+)
+
+// checkInterval is the number of converted AST structs between checks of the context.
+const checkInterval = 256
+
+// converter converts AST values to native nodes.
+//
+// AST nodes are converted iteratively: each node is converted to an object with all fields, except
+// the child nodes, which are queued as tasks that fill the fields later. Node types and fields listed
+// in astFields are converted without reflection; others are converted by toNode.
+type converter struct {
+	ctx context.Context
+	// done is nil if the context cannot be canceled
+	done <-chan struct{}
+	fs   *token.FileSet
+	lim  Limits
+	// structs is the number of converted structs, and depth is the current nesting level of structs
+	// in toNode
+	structs int
+	depth   int
+	// file is the last file of the file set that contained a position
+	file  *token.File
+	tasks []task
+}
+
+// task is a pending conversion of a child node. The result is stored either in the field
+// of a parent object, or in the element of an array.
+type task struct {
+	node  ast.Node
+	depth int
+	obj   nodes.Object
+	key   string
+	arr   nodes.Array
+	idx   int
+}
+
+// check is called for each converted struct. It returns an error if the limits are exceeded
+// or if the context is canceled. The context is only checked periodically.
+func (c *converter) check(depth int) error {
+	c.structs++
+	if c.lim.MaxNodes > 0 && c.structs > c.lim.MaxNodes {
+		return &LimitError{Limit: LimitNodes, Max: c.lim.MaxNodes}
+	}
+	if c.lim.MaxDepth > 0 && depth > c.lim.MaxDepth {
+		return &LimitError{Limit: LimitDepth, Max: c.lim.MaxDepth}
+	}
+	if c.done == nil || c.structs%checkInterval != 0 {
+		return nil
+	}
+	select {
+	case <-c.done:
+		return &CanceledError{Err: c.ctx.Err()}
+	default:
+		return nil
+	}
+}
+
+// convert converts the tree with a given root node. The root is converted at a given nesting level.
+func (c *converter) convert(root ast.Node, depth int) (nodes.Node, error) {
+	out := make(nodes.Array, 1)
+	c.tasks = append(c.tasks[:0], task{node: root, depth: depth, arr: out})
+	for len(c.tasks) != 0 {
+		t := c.tasks[len(c.tasks)-1]
+		c.tasks = c.tasks[:len(c.tasks)-1]
+		n, err := c.node(t.node, t.depth)
+		if err != nil {
+			return nil, err
+		}
+		if t.obj != nil {
+			t.obj[t.key] = n
+		} else {
+			t.arr[t.idx] = n
+		}
+	}
+	return out[0], nil
+}
+
+// field queues the conversion of a child node into the field of the object. The node is converted
+// at the nesting level that follows the level of the parent.
+func (c *converter) field(obj nodes.Object, key string, n ast.Node, depth int) {
+	if n == nil {
+		obj[key] = nil
+		return
+	}
+	c.tasks = append(c.tasks, task{node: n, depth: depth + 1, obj: obj, key: key})
+}
+
+// elem queues the conversion of a child node into the element of the array.
+func (c *converter) elem(arr nodes.Array, i int, n ast.Node, depth int) {
+	c.tasks = append(c.tasks, task{node: n, depth: depth + 1, arr: arr, idx: i})
+}
+
+// position converts a position the same way as convertPosition.
+func (c *converter) position(p token.Pos) nodes.Object {
+	var pos token.Position
+	if p.IsValid() {
+		if f := c.file; f == nil || int(p) < f.Base() || int(p) > f.Base()+f.Size() {
+			c.file = c.fs.File(p)
+		}
+		if c.file != nil {
+			pos = c.file.Position(p)
+		}
+	}
+	// see uast.Position
+	return nodes.Object{
+		uast.KeyType: nodes.String(uast.TypePosition),
+		"offset":     nodes.Uint(pos.Offset),
+		"line":       nodes.Uint(pos.Line),
+		"col":        nodes.Uint(pos.Column),
+	}
+}
+
+// positions returns an object with the start and the end positions of the node, with a space
+// for additional positions.
+func (c *converter) positions(n ast.Node, extra int) nodes.Object {
+	pos := make(nodes.Object, 3+extra)
+	pos[uast.KeyType] = nodes.String(uast.TypePositions)
+	pos[uast.KeyStart] = c.position(n.Pos())
+	pos[uast.KeyEnd] = c.position(n.End())
+	return pos
+}
+
+// extraFields lists indexes of fields of AST node types that are not listed in astFields.
+// They are fields added to go/ast after the minimum supported release of Go (see gen).
+var extraFields = make(map[reflect.Type][]int)
+
+func init() {
+	for name, fields := range astFields {
+		t := typeNameToType[name]
+		known := make(map[string]struct{}, len(fields))
+		for _, f := range fields {
+			known[f] = struct{}{}
+		}
+		for i := 0; i < t.NumField(); i++ {
+			if _, ok := known[t.Field(i).Name]; !ok {
+				extraFields[reflect.PtrTo(t)] = append(extraFields[reflect.PtrTo(t)], i)
+			}
+		}
+	}
+}
+
+// extra converts fields of the node that are listed in extraFields and returns the object.
+func (c *converter) extra(obj, pos nodes.Object, n ast.Node, depth int) (nodes.Node, error) {
+	if len(extraFields) == 0 {
+		return obj, nil
+	}
+	idx := extraFields[reflect.TypeOf(n)]
+	if len(idx) == 0 {
+		return obj, nil
+	}
+	val := reflect.ValueOf(n).Elem()
+	t := val.Type()
+	for _, i := range idx {
+		f := t.Field(i)
+		switch f.Type {
+		case PosType:
+			pos[f.Name] = c.position(val.Field(i).Interface().(token.Pos))
+		case ScopeType, ObjectType:
+		default:
+			c.depth = depth
+			v, err := c.toNode(val.Field(i))
+			if err != nil {
+				return nil, err
+			}
+			obj[f.Name] = v
+		}
+	}
+	return obj, nil
+}
@@ -0,0 +1,116 @@
+package golang
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+)
+
+// CanceledError is returned when parsing or conversion of the source code is interrupted,
+// because the context was canceled or its deadline was exceeded. Err is the error of the context.
+type CanceledError struct {
+	Err error
+}
+
+func (e *CanceledError) Error() string {
+	return "go driver: parsing interrupted: " + e.Err.Error()
+}
+
+// Unwrap returns the error of the context.
+func (e *CanceledError) Unwrap() error {
+	return e.Err
+}
+
+// ErrLimitExceeded matches all errors returned when the source code exceeds one of Limits.
+var ErrLimitExceeded = errors.New("go driver: limit exceeded")
+
+// Names of limits reported by LimitError.
+const (
+	LimitSourceBytes = "source bytes"
+	LimitNodes       = "nodes"
+	LimitDepth       = "depth"
+)
+
+// LimitError is returned when the source code exceeds one of Limits.
+type LimitError struct {
+	// Limit is a name of the exceeded limit: LimitSourceBytes, LimitNodes or LimitDepth.
+	Limit string
+	// Max is the value of the limit.
+	Max int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v: %s (max %d)", ErrLimitExceeded, e.Limit, e.Max)
+}
+
+// Is reports if the target is ErrLimitExceeded.
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// ConvertError is returned by NodeToASTErr when a native node cannot be converted to an AST node.
+type ConvertError struct {
+	// Path is the path to the node from the root of the tree, like "Decls[0].Body.List[1]".
+	// It is empty for the root and when the node is unknown.
+	Path string
+	// Type is the type of the node.
+	Type string
+	// Reason describes the problem.
+	Reason string
+
+	// node is the object that cannot be converted
+	node nodes.Object
+}
+
+func (e *ConvertError) Error() string {
+	msg := "go driver: cannot convert native node"
+	if e.Type != "" {
+		msg += " " + e.Type
+	}
+	if e.Path != "" {
+		msg += " at " + e.Path
+	}
+	return msg + ": " + e.Reason
+}
+
+// newConvertError returns ConvertError for a panic value recovered during the conversion of the tree.
+func newConvertError(root nodes.Node, r interface{}) *ConvertError {
+	e, ok := r.(*ConvertError)
+	if !ok {
+		return &ConvertError{Type: uast.TypeOf(root), Reason: fmt.Sprint(r)}
+	}
+	if e.node != nil {
+		e.Path, _ = nodePath(root, e.node, "")
+	}
+	return e
+}
+
+// nodePath returns the path to the object in the tree. Objects are compared by identity.
+func nodePath(root nodes.Node, target nodes.Object, path string) (string, bool) {
+	switch n := root.(type) {
+	case nodes.Object:
+		if reflect.ValueOf(n).Pointer() == reflect.ValueOf(target).Pointer() {
+			return path, true
+		}
+		for _, k := range n.Keys() {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			if p, ok := nodePath(n[k], target, p); ok {
+				return p, true
+			}
+		}
+	case nodes.Array:
+		for i, v := range n {
+			if p, ok := nodePath(v, target, path+"["+strconv.Itoa(i)+"]"); ok {
+				return p, true
+			}
+		}
+	}
+	return "", false
+}
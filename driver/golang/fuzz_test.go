@@ -0,0 +1,181 @@
+//go:build go1.18
+// +build go1.18
+
+package golang
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+	uastyml "github.com/bblfsh/sdk/v3/uast/yaml"
+)
+
+// FuzzNodeToASTErr checks that NodeToASTErr does not panic on arbitrary native trees.
+//
+// Random bytes rarely decode to a native tree, thus the fuzzer mutates native trees of fixtures
+// instead: the input selects one of the trees and encodes a list of mutations (see mutateTree).
+// Only small fixtures are used to keep the fuzzer fast.
+func FuzzNodeToASTErr(f *testing.F) {
+	files, err := filepath.Glob(filepath.Join(fixtures, "*.go.native"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	var trees []nodes.Node
+	for _, name := range files {
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			f.Fatal(err)
+		}
+		n, err := uastyml.Unmarshal(data)
+		if err != nil {
+			f.Fatal(err)
+		}
+		if len(treeSlots(nodes.Array{n})) <= maxFuzzNodes {
+			trees = append(trees, n)
+		}
+	}
+	if len(trees) == 0 {
+		f.Fatal("no fixtures found")
+	}
+	for i := range trees {
+		f.Add(uint16(i), []byte{})
+	}
+	f.Fuzz(func(t *testing.T, tree uint16, ops []byte) {
+		n := mutateTree(cloneTree(trees[int(tree)%len(trees)]), ops)
+		if _, err := NodeToASTErr(n); err != nil {
+			if _, ok := err.(*ConvertError); !ok {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	})
+}
+
+const (
+	// maxFuzzNodes is the maximal number of nodes in fixtures used by the fuzzer.
+	maxFuzzNodes = 2000
+	// mutationSize is the number of bytes that encode a single mutation: its kind, the index of the target
+	// node in pre-order (two bytes) and an argument.
+	mutationSize = 4
+	// maxMutations is the maximal number of mutations applied to a tree.
+	maxMutations = 32
+)
+
+// mutationStrings are values of string nodes set by mutations, in addition to names of node types.
+var mutationStrings = []string{"", "+", "IDENT", "STRING", "func", uast.KeyType, uast.KeyPos}
+
+// mutateTree applies mutations encoded in ops to the tree and returns the new root.
+func mutateTree(root nodes.Node, ops []byte) nodes.Node {
+	strs := append([]string{}, mutationStrings...)
+	for name := range typeNameToType {
+		strs = append(strs, name)
+	}
+	sort.Strings(strs[len(mutationStrings):])
+
+	// the root is stored in an array, so it can be replaced as well
+	top := nodes.Array{root}
+	if len(ops) > maxMutations*mutationSize {
+		ops = ops[:maxMutations*mutationSize]
+	}
+	for ; len(ops) >= mutationSize; ops = ops[mutationSize:] {
+		slots := treeSlots(top)
+		s := slots[int(binary.BigEndian.Uint16(ops[1:3]))%len(slots)]
+		arg := ops[3]
+		switch ops[0] % 10 {
+		case 0:
+			s.set(nil)
+		case 1:
+			s.set(nodes.String(strs[int(arg)%len(strs)]))
+		case 2:
+			s.set(nodes.Int(int8(arg)))
+		case 3:
+			s.set(nodes.Uint(arg))
+		case 4:
+			s.set(nodes.Float(arg) / 2)
+		case 5:
+			s.set(nodes.Bool(arg%2 == 0))
+		case 6:
+			s.set(nodes.Object{})
+		case 7:
+			s.set(nodes.Array{})
+		case 8:
+			// copy another node of the tree, unless the tree is already too large
+			if len(slots) < 2*maxFuzzNodes {
+				s.set(cloneTree(slots[int(arg)*len(slots)/256].get()))
+			}
+		case 9:
+			// drop the field
+			if s.obj != nil {
+				delete(s.obj, s.key)
+			}
+		}
+	}
+	return top[0]
+}
+
+// treeSlot is a location of a node in the tree: either a field of an object or an element of an array.
+type treeSlot struct {
+	obj nodes.Object
+	key string
+	arr nodes.Array
+	idx int
+}
+
+func (s treeSlot) get() nodes.Node {
+	if s.obj != nil {
+		return s.obj[s.key]
+	}
+	return s.arr[s.idx]
+}
+
+func (s treeSlot) set(n nodes.Node) {
+	if s.obj != nil {
+		s.obj[s.key] = n
+	} else {
+		s.arr[s.idx] = n
+	}
+}
+
+// treeSlots lists locations of all nodes of the tree in pre-order. Fields of objects are sorted by keys.
+func treeSlots(top nodes.Array) []treeSlot {
+	var out []treeSlot
+	var walk func(s treeSlot)
+	walk = func(s treeSlot) {
+		out = append(out, s)
+		switch n := s.get().(type) {
+		case nodes.Object:
+			for _, k := range n.Keys() {
+				walk(treeSlot{obj: n, key: k})
+			}
+		case nodes.Array:
+			for i := range n {
+				walk(treeSlot{arr: n, idx: i})
+			}
+		}
+	}
+	walk(treeSlot{arr: top})
+	return out
+}
+
+// cloneTree returns a deep copy of the tree. Unlike Clone methods of nodes, it allows nil elements of arrays.
+func cloneTree(n nodes.Node) nodes.Node {
+	switch n := n.(type) {
+	case nodes.Object:
+		out := make(nodes.Object, len(n))
+		for k, v := range n {
+			out[k] = cloneTree(v)
+		}
+		return out
+	case nodes.Array:
+		out := make(nodes.Array, len(n))
+		for i, v := range n {
+			out[i] = cloneTree(v)
+		}
+		return out
+	}
+	return n
+}
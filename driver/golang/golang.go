@@ -2,11 +2,13 @@ package golang
 
 import (
 	"context"
+	"errors"
 	"go/ast"
 	"go/parser"
 	"go/token"
 	"reflect"
 
+	"github.com/bblfsh/sdk/v3/driver"
 	"github.com/bblfsh/sdk/v3/uast/nodes"
 
 	"github.com/opentracing/opentracing-go"
@@ -21,19 +23,142 @@ func ParseString(code string) (*ast.File, *token.FileSet, error) {
 	return tree, fs, nil
 }
 
+// ParseStringContext is like ParseString, but returns CanceledError if the context is canceled
+// or its deadline is exceeded. The Go parser cannot be interrupted, thus the context is only checked
+// before and after parsing; Limits.MaxSourceBytes bounds the time spent in the parser instead.
+func ParseStringContext(ctx context.Context, code string) (*ast.File, *token.FileSet, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, &CanceledError{Err: err}
+	}
+	tree, fs, err := ParseString(code)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, &CanceledError{Err: err}
+	}
+	return tree, fs, nil
+}
+
 func Parse(code string) (nodes.Node, error) {
-	f, fs, err := ParseString(code)
+	return ParseWithOptions(code, Options{})
+}
+
+// ParseContext is like Parse, but stops with CanceledError when the context is canceled
+// or its deadline is exceeded.
+func ParseContext(ctx context.Context, code string) (nodes.Node, error) {
+	return ParseWithOptionsContext(ctx, code, Options{})
+}
+
+// Options enables optional analyses of the source code. Results of the analyses are stored
+// as additional fields of native nodes, thus they are available in all UAST modes.
+type Options struct {
+	// Captures lists variables that function literals capture from enclosing functions.
+	// It sets Captures and LoopVarCapture fields of FuncLit nodes.
+	Captures bool
+	// Metrics computes complexity metrics of functions: cyclomatic and cognitive complexity,
+	// nesting depth, the number of statements and parameters. It sets the Metrics field
+	// of FuncDecl and FuncLit nodes.
+	Metrics bool
+	// Calls resolves callees of call expressions and lists caller→callee edges in the Calls
+	// field of the File node. Calls of interface methods and function values are marked as dynamic.
+	Calls bool
+	// Scopes reconstructs the tree of lexical scopes (universe, package, file, function and block)
+	// with objects declared in each of them, and stores it in the Scopes field of the File node.
+	Scopes bool
+	// IDs assigns a deterministic identifier to each native node and stores it under KeyID.
+	// Nodes created by other analyses have no IDs.
+	IDs bool
+	// Lean omits nil fields of native nodes and positions of fields that can be derived from the start
+	// and the end positions of nodes. RestoreLean restores them, while NodeToAST accepts both forms.
+	// The normalizer requires all fields, thus the option is only suitable for native trees.
+	Lean bool
+	// CompactPositions keeps only offsets of the start and the end of each node. Lines and columns
+	// can be restored by ExpandPositions from the line table stored under KeyLines of the File node.
+	// The normalizer requires all positions, thus the option is only suitable for native trees.
+	CompactPositions bool
+	// Limits restricts resources used to parse a single file. LimitError is returned if the file
+	// exceeds any of them.
+	Limits Limits
+}
+
+// Limits restricts the size of the source code and of the native tree. Zero values mean no limit.
+type Limits struct {
+	// MaxSourceBytes is the maximal size of the source code in bytes.
+	MaxSourceBytes int
+	// MaxNodes is the maximal number of native nodes that correspond to Go AST structs.
+	MaxNodes int
+	// MaxDepth is the maximal nesting depth of such nodes.
+	MaxDepth int
+}
+
+// DefaultLimits are limits used by the driver server. A file of the size limit has about
+// 750 thousand nodes, while the depth limit is only reached by pathological expressions.
+var DefaultLimits = Limits{
+	MaxSourceBytes: 4 << 20,
+	MaxNodes:       1 << 20,
+	MaxDepth:       10000,
+}
+
+// ParseWithOptions is like Parse, but also runs optional analyses enabled in opts.
+func ParseWithOptions(code string, opts Options) (nodes.Node, error) {
+	return ParseWithOptionsContext(context.Background(), code, opts)
+}
+
+// ParseWithOptionsContext is like ParseWithOptions, but stops with CanceledError when the context
+// is canceled or its deadline is exceeded. The conversion of the tree checks the context periodically,
+// while the Go parser runs to completion (see ParseStringContext).
+func ParseWithOptionsContext(ctx context.Context, code string, opts Options) (nodes.Node, error) {
+	if max := opts.Limits.MaxSourceBytes; max > 0 && len(code) > max {
+		return nil, &LimitError{Limit: LimitSourceBytes, Max: max}
+	}
+	f, fs, err := ParseStringContext(ctx, code)
 	if err != nil {
 		return nil, err
 	}
-	return ValueToNode(reflect.ValueOf(f), fs)
+	n, err := valueToNode(ctx, reflect.ValueOf(f), fs, opts.Limits)
+	if err != nil {
+		return nil, err
+	}
+	if opts.IDs {
+		assignIDs(n)
+	}
+	if opts.Captures {
+		n = attachFields(n, fs, "FuncLit", captures(f, fs))
+	}
+	if opts.Metrics {
+		m := metrics(f)
+		n = attachFields(n, fs, "FuncDecl", m)
+		n = attachFields(n, fs, "FuncLit", m)
+	}
+	if opts.Calls {
+		n = attachFields(n, fs, "File", calls(f, fs))
+	}
+	if opts.Scopes {
+		n = attachFields(n, fs, "File", scopes(f, fs))
+	}
+	if opts.Lean {
+		leanNodes(n)
+	}
+	if opts.CompactPositions {
+		compactPositions(n)
+		n.(nodes.Object)[KeyLines] = lineTable(code)
+	}
+	return n, nil
 }
 
 func NewDriver() *Driver {
 	return &Driver{}
 }
 
-type Driver struct{}
+// NewDriverWithOptions creates a driver that runs optional analyses enabled in opts.
+func NewDriverWithOptions(opts Options) *Driver {
+	return &Driver{opts: opts}
+}
+
+type Driver struct {
+	opts Options
+}
 
 func (Driver) Start() error {
 	return nil
@@ -41,9 +166,14 @@ func (Driver) Start() error {
 func (Driver) Close() error {
 	return nil
 }
-func (Driver) Parse(ctx context.Context, code string) (nodes.Node, error) {
+func (d Driver) Parse(ctx context.Context, code string) (nodes.Node, error) {
 	sp, _ := opentracing.StartSpanFromContext(ctx, "go.Parse")
 	defer sp.Finish()
 
-	return Parse(code)
+	n, err := ParseWithOptionsContext(ctx, code, d.opts)
+	if errors.Is(err, ErrLimitExceeded) {
+		// not a syntax error
+		return nil, driver.ErrDriverFailure.Wrap(err)
+	}
+	return n, err
 }
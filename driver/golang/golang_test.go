@@ -3,18 +3,25 @@ package golang
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"go/ast"
 	"go/format"
 	"go/parser"
 	"go/printer"
 	"go/token"
 	"io/ioutil"
+	"math/rand"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/bblfsh/sdk/v3/driver"
 	"github.com/bblfsh/sdk/v3/uast"
 	"github.com/bblfsh/sdk/v3/uast/nodes"
 	"github.com/bblfsh/sdk/v3/uast/transformer"
+	uastyml "github.com/bblfsh/sdk/v3/uast/yaml"
 
 	"github.com/stretchr/testify/require"
 )
@@ -117,6 +124,163 @@ func TestStringReplaceTransform(t *testing.T) {
 	require.Equal(t, expCode, buf.String(), buf.String())
 }
 
+func TestTransform(t *testing.T) {
+	const code = `package main
+
+import "fmt"
+
+// greet prints a greeting.
+func greet(name string) {
+	// say hello
+	fmt.Println("foo", name) // trailing
+
+	// removed
+	fmt.Println("remove me")
+	fmt.Println("done")
+}
+`
+	ident := func(name string) nodes.Object {
+		return nodes.Object{uast.KeyType: nodes.String("Ident"), "Name": nodes.String(name)}
+	}
+	// block replaces the second statement of the block with given statements
+	block := func(stmts ...transformer.Op) transformer.Transformer {
+		return transformer.Mappings(transformer.Map(
+			transformer.Part("block", transformer.Obj{
+				uast.KeyType: transformer.String("BlockStmt"),
+				"List":       transformer.Arr(transformer.Var("a"), transformer.Var("b"), transformer.Var("c")),
+			}),
+			transformer.Part("block", transformer.Obj{
+				uast.KeyType: transformer.String("BlockStmt"),
+				"List":       transformer.Arr(stmts...),
+			}),
+		))
+	}
+	panicStmt := transformer.Is(nodes.Object{
+		uast.KeyType: nodes.String("ExprStmt"),
+		"X": nodes.Object{
+			uast.KeyType: nodes.String("CallExpr"),
+			"Fun":        ident("panic"),
+			"Args": nodes.Array{nodes.Object{
+				uast.KeyType: nodes.String("BinaryExpr"),
+				"X":          ident("name"),
+				"Op":         nodes.String("+"),
+				"Y":          ident("name"),
+			}},
+		},
+	})
+
+	cases := []struct {
+		name string
+		t    transformer.Transformer
+		exp  string
+	}{
+		{
+			name: "replace",
+			t: transformer.Mappings(transformer.Map(
+				transformer.String(`"foo"`),
+				transformer.String(`"a much longer string"`),
+			)),
+			exp: strings.Replace(code, `"foo"`, `"a much longer string"`, 1),
+		},
+		{
+			name: "delete",
+			t:    block(transformer.Var("a"), transformer.Var("c")),
+			exp:  strings.Replace(code, "\tfmt.Println(\"remove me\")\n", "\n", 1),
+		},
+		{
+			name: "insert",
+			t:    block(transformer.Var("a"), panicStmt, transformer.Var("b"), transformer.Var("c")),
+			exp: strings.Replace(code, "// trailing\n",
+				"// trailing\n\tpanic(name + name)\n", 1),
+		},
+		{
+			// members of imported packages are not known
+			name: "package member",
+			t: transformer.Mappings(transformer.Map(
+				transformer.String("Println"),
+				transformer.String("Printf"),
+			)),
+			exp: strings.Replace(code, "Println", "Printf", -1),
+		},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			out, err := Transform(code, c.t)
+			require.NoError(t, err)
+			require.Equal(t, c.exp, out)
+		})
+	}
+
+	// the tree that cannot be printed as valid code
+	_, err := Transform(code, block(transformer.Var("a"), transformer.Is(ident("x")), transformer.Var("c")))
+	require.Error(t, err)
+
+	// trees that are printed as valid code that does not type-check
+	file := func(fnc func(decls nodes.Array) nodes.Array) transformer.Transformer {
+		return transformer.TransformObjFunc(func(n nodes.Object) (nodes.Object, bool, error) {
+			if uast.TypeOf(n) != "File" {
+				return n, false, nil
+			}
+			n = n.CloneObject()
+			n["Decls"] = fnc(n["Decls"].(nodes.Array))
+			return n, true, nil
+		})
+	}
+	errCases := []struct {
+		name string
+		t    transformer.Transformer
+		err  string
+	}{
+		{
+			name: "dropped import",
+			t:    file(func(decls nodes.Array) nodes.Array { return decls[1:] }),
+			err:  ": fmt",
+		},
+		{
+			name: "duplicate declaration",
+			t:    file(func(decls nodes.Array) nodes.Array { return append(decls, decls[1]) }),
+			err:  "greet redeclared in this block",
+		},
+		{
+			name: "broken reference",
+			t: transformer.TransformObjFunc(func(n nodes.Object) (nodes.Object, bool, error) {
+				if uast.TypeOf(n) != "BasicLit" || n["Value"] != nodes.String(`"foo"`) {
+					return n, false, nil
+				}
+				return ident("undefined"), true, nil
+			}),
+			err: ": undefined",
+		},
+	}
+	for _, c := range errCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			_, err := Transform(code, c.t)
+			require.Error(t, err)
+			require.True(t, strings.Contains(err.Error(), c.err), "%v", err)
+		})
+	}
+}
+
+func TestFormatFixtures(t *testing.T) {
+	files, err := selectFiles()
+	require.NoError(t, err)
+
+	for _, f := range files {
+		t.Run(filepath.Base(f), func(t *testing.T) {
+			data, err := ioutil.ReadFile(f)
+			require.NoError(t, err)
+			exp, err := format.Source(data)
+			require.NoError(t, err)
+
+			out, err := Transform(string(data), transformer.Mappings())
+			require.NoError(t, err)
+			require.Equal(t, string(exp), out)
+		})
+	}
+}
+
 // TestUASTNodeToCode
 // 1) parse code ${exp_code} to UAST node
 // 2) convert UAST node to AST node
@@ -156,6 +320,371 @@ func TestUASTNodeToCode(t *testing.T) {
 	}
 }
 
+func TestCaptures(t *testing.T) {
+	const code = `package main
+
+var global int
+
+func main() {
+	x := 1
+	for i := 0; i < 3; i++ {
+		go func() {
+			println(i, x, global)
+		}()
+		defer func(v int) {
+			println(v)
+		}(i)
+	}
+	f := func() {
+		y := x
+		_ = y
+	}
+	f()
+	go func() {
+		for _, v := range []int{1} {
+			g := func() {
+				println(v)
+			}
+			g()
+		}
+	}()
+}
+`
+	ast, err := ParseWithOptions(code, Options{Captures: true})
+	require.NoError(t, err)
+
+	type capture struct {
+		Name    string
+		Line    uint32
+		Uses    int
+		LoopVar bool
+	}
+	type funcLit struct {
+		Captures       []capture
+		LoopVarCapture bool
+	}
+	var lits []funcLit
+	nodes.WalkPreOrder(ast, func(n nodes.Node) bool {
+		obj, ok := n.(nodes.Object)
+		if !ok || uast.TypeOf(obj) != "FuncLit" {
+			return true
+		}
+		var lit funcLit
+		lit.LoopVarCapture = bool(obj["LoopVarCapture"].(nodes.Bool))
+		for _, c := range obj["Captures"].(nodes.Array) {
+			c := c.(nodes.Object)
+			lit.Captures = append(lit.Captures, capture{
+				Name:    string(c["Name"].(nodes.String)),
+				Line:    uast.PositionsOf(c).Start().Line,
+				Uses:    len(c["Uses"].(nodes.Array)),
+				LoopVar: bool(c["LoopVar"].(nodes.Bool)),
+			})
+		}
+		lits = append(lits, lit)
+		return true
+	})
+	require.Equal(t, []funcLit{
+		{Captures: []capture{
+			{Name: "i", Line: 7, Uses: 1, LoopVar: true},
+			{Name: "x", Line: 6, Uses: 1},
+		}, LoopVarCapture: true},
+		{},
+		{Captures: []capture{
+			{Name: "x", Line: 6, Uses: 1},
+		}},
+		{},
+		// the nested literal is called synchronously by the goroutine
+		{Captures: []capture{
+			{Name: "v", Line: 21, Uses: 1, LoopVar: true},
+		}},
+	}, lits)
+
+	// analysis fields are ignored when converting back to Go AST
+	act, err := nodeToCode(ast)
+	require.NoError(t, err)
+	require.Equal(t, code, act)
+}
+
+func TestMetrics(t *testing.T) {
+	const code = `package main
+
+func fact(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	return n * fact(n-1)
+}
+
+func classify(xs []int) (n int) {
+	for _, x := range xs {
+		switch {
+		case x > 0 && x < 10 || x == 42:
+			n++
+		case x < 0:
+			continue
+		default:
+			if x == 0 {
+				n--
+			} else {
+				break
+			}
+		}
+	}
+	f := func(a, b int, _ string) bool {
+		return a > b
+	}
+	_ = f
+	return
+}
+`
+	ast, err := ParseWithOptions(code, Options{Metrics: true})
+	require.NoError(t, err)
+
+	type metrics struct {
+		Type                  string
+		Cyclomatic, Cognitive int
+		MaxNesting            int
+		Statements, Params    int
+	}
+	var act []metrics
+	nodes.WalkPreOrder(ast, func(n nodes.Node) bool {
+		obj, ok := n.(nodes.Object)
+		if !ok || (uast.TypeOf(obj) != "FuncDecl" && uast.TypeOf(obj) != "FuncLit") {
+			return true
+		}
+		m := obj["Metrics"].(nodes.Object)
+		act = append(act, metrics{
+			Type:       uast.TypeOf(obj),
+			Cyclomatic: int(m["Cyclomatic"].(nodes.Int)),
+			Cognitive:  int(m["Cognitive"].(nodes.Int)),
+			MaxNesting: int(m["MaxNesting"].(nodes.Int)),
+			Statements: int(m["Statements"].(nodes.Int)),
+			Params:     int(m["Params"].(nodes.Int)),
+		})
+		return true
+	})
+	require.Equal(t, []metrics{
+		{Type: "FuncDecl", Cyclomatic: 2, Cognitive: 2, MaxNesting: 1, Statements: 3, Params: 1},
+		{Type: "FuncDecl", Cyclomatic: 7, Cognitive: 9, MaxNesting: 3, Statements: 10, Params: 1},
+		{Type: "FuncLit", Cyclomatic: 1, Cognitive: 0, MaxNesting: 0, Statements: 1, Params: 3},
+	}, act)
+
+	// analysis fields are ignored when converting back to Go AST
+	plain, err := Parse(code)
+	require.NoError(t, err)
+	exp, err := nodeToCode(plain)
+	require.NoError(t, err)
+	got, err := nodeToCode(ast)
+	require.NoError(t, err)
+	require.Equal(t, exp, got)
+}
+
+func TestCalls(t *testing.T) {
+	const code = `package main
+
+import (
+	"fmt"
+	str "strings"
+)
+
+type Greeter interface {
+	Greet(name string) string
+}
+
+type english struct {
+	suffix func() string
+}
+
+func (e *english) Greet(name string) string {
+	return "Hello, " + name + e.suffix()
+}
+
+func greet(g Greeter) {
+	fmt.Println(g.Greet("world"))
+}
+
+var upper = str.ToUpper
+
+func main() {
+	e := &english{suffix: func() string { return "!" }}
+	e.Greet(upper("go"))
+	greet(e)
+	f := greet
+	f(Greeter(e))
+	func() {}()
+	_ = len("x")
+	_ = float64(len(string(e.suffix())))
+}
+`
+	ast, err := ParseWithOptions(code, Options{Calls: true})
+	require.NoError(t, err)
+
+	type call struct {
+		Line                 uint32
+		Caller, Callee, Kind string
+		Package              string
+		Dynamic              bool
+	}
+	var act []call
+	for _, c := range ast.(nodes.Object)["Calls"].(nodes.Array) {
+		c := c.(nodes.Object)
+		pkg, _ := c["Package"].(nodes.String)
+		act = append(act, call{
+			Line:    uast.PositionsOf(c).Start().Line,
+			Caller:  string(c["Caller"].(nodes.String)),
+			Callee:  string(c["Callee"].(nodes.String)),
+			Kind:    string(c["Kind"].(nodes.String)),
+			Package: string(pkg),
+			Dynamic: bool(c["Dynamic"].(nodes.Bool)),
+		})
+	}
+	require.Equal(t, []call{
+		{Line: 17, Caller: "english.Greet", Callee: "english.suffix", Kind: "value", Dynamic: true},
+		{Line: 21, Caller: "greet", Callee: "Println", Kind: "function", Package: "fmt"},
+		{Line: 21, Caller: "greet", Callee: "Greeter.Greet", Kind: "interface", Dynamic: true},
+		{Line: 28, Caller: "main", Callee: "english.Greet", Kind: "method"},
+		{Line: 28, Caller: "main", Callee: "upper", Kind: "value", Dynamic: true},
+		{Line: 29, Caller: "main", Callee: "greet", Kind: "function"},
+		{Line: 31, Caller: "main", Callee: "f", Kind: "value", Dynamic: true},
+		{Line: 32, Caller: "main", Kind: "literal"},
+		{Line: 33, Caller: "main", Callee: "len", Kind: "builtin"},
+		// conversions to predeclared types are not calls
+		{Line: 34, Caller: "main", Callee: "len", Kind: "builtin"},
+		{Line: 34, Caller: "main", Callee: "english.suffix", Kind: "value", Dynamic: true},
+	}, act)
+
+	// analysis fields are ignored when converting back to Go AST
+	_, err = nodeToCode(ast)
+	require.NoError(t, err)
+}
+
+func TestCallsGenerics(t *testing.T) {
+	const code = `package main
+
+type Map[K comparable, V any] struct {
+	m map[K]V
+}
+
+func (m *Map[K, V]) Get(k K) V {
+	return m.m[k]
+}
+
+func (m *Map[K, V]) Has(k K) bool {
+	_ = m.Get(k)
+	return true
+}
+
+func main() {
+	var m Map[string, int]
+	m.Has("x")
+}
+`
+	ast, err := ParseWithOptions(code, Options{Calls: true})
+	if err != nil {
+		t.Skip(err)
+	}
+	type call struct {
+		Line           uint32
+		Caller, Callee string
+		Kind           string
+	}
+	var act []call
+	for _, c := range ast.(nodes.Object)["Calls"].(nodes.Array) {
+		c := c.(nodes.Object)
+		act = append(act, call{
+			Line:   uast.PositionsOf(c).Start().Line,
+			Caller: string(c["Caller"].(nodes.String)),
+			Callee: string(c["Callee"].(nodes.String)),
+			Kind:   string(c["Kind"].(nodes.String)),
+		})
+	}
+	// receivers with multiple type parameters resolve to the generic type
+	require.Equal(t, []call{
+		{Line: 12, Caller: "Map.Has", Callee: "Map.Get", Kind: "method"},
+		{Line: 18, Caller: "main", Callee: "Map.Has", Kind: "method"},
+	}, act)
+}
+
+func TestScopes(t *testing.T) {
+	const code = `package main
+
+import (
+	"fmt"
+	_ "net/http/pprof"
+)
+
+type T struct {
+	X int
+}
+
+var global = func(cb func(v int)) {}
+
+func (t *T) Get(def int) (res int) {
+	if x := t.X; x != 0 {
+		return x
+	}
+	return def
+}
+
+func main() {
+loop:
+	for i := 0; i < 3; i++ {
+		switch v := interface{}(i).(type) {
+		case int:
+			fmt.Println(v)
+			break loop
+		}
+	}
+}
+`
+	ast, err := ParseWithOptions(code, Options{Scopes: true})
+	require.NoError(t, err)
+
+	var (
+		buf   strings.Builder
+		print func(s nodes.Object, indent string)
+	)
+	print = func(s nodes.Object, indent string) {
+		kind := string(s["Kind"].(nodes.String))
+		buf.WriteString(indent + kind)
+		if start := uast.PositionsOf(s).Start(); start != nil {
+			buf.WriteString(fmt.Sprintf(" %d", start.Line))
+		}
+		buf.WriteString(":")
+		objs := s["Objects"].(nodes.Array)
+		if kind == "universe" {
+			buf.WriteString(fmt.Sprintf(" %d objects", len(objs)))
+			objs = nil
+		}
+		for _, o := range objs {
+			o := o.(nodes.Object)
+			buf.WriteString(fmt.Sprintf(" %s(%s, %s)", o["Name"], o["Kind"], o["Node"]))
+		}
+		buf.WriteString("\n")
+		for _, c := range s["Scopes"].(nodes.Array) {
+			print(c.(nodes.Object), indent+"  ")
+		}
+	}
+	print(ast.(nodes.Object)["Scopes"].(nodes.Object), "")
+	require.Equal(t, `universe: 44 objects
+  package 1: T(type, Ident:58:59) global(var, Ident:83:89) main(func, Ident:211:215)
+    file 1: fmt(package, ImportSpec:24:29)
+      function 12: cb(var, Ident:97:99)
+      function 14: t(var, Ident:123:124) def(var, Ident:133:136) res(var, Ident:143:146)
+        block 15: x(var, Ident:158:159)
+          block 15:
+      function 21: loop(label, Ident:220:224)
+        block 23: i(var, Ident:231:232)
+          block 23:
+            block 24: v(var, Ident:261:262)
+              block 25:
+`, buf.String())
+
+	// analysis fields are ignored when converting back to Go AST
+	_, err = nodeToCode(ast)
+	require.NoError(t, err)
+}
+
 func nodeToCode(n nodes.Node) (string, error) {
 	astNode := NodeToAST(n)
 
@@ -221,3 +750,636 @@ func getWhiteListMap() (map[string]struct{}, error) {
 	}
 	return res, nil
 }
+
+const reparseCode = `package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Greeting is a greeting.
+const Greeting = "hello"
+
+type Point struct {
+	X, Y int // coordinates
+}
+
+// Dist returns a squared distance from the origin.
+func (p Point) Dist() int {
+	return p.X*p.X + p.Y*p.Y
+}
+
+var names = []string{"a", "b"}
+
+func main() {
+	// print
+	fmt.Println(strings.ToUpper(Greeting), names)
+}
+
+func helper(x int) int { return x + undefined }
+`
+
+// requireReparse checks that Reparse returns the same tree as Parse for the edited code.
+func requireReparse(t testing.TB, code string, prev nodes.Node, e Edit) nodes.Node {
+	next, err := e.Apply(code)
+	require.NoError(t, err)
+	exp, experr := Parse(next)
+	got, err := Reparse(code, prev, e)
+	if experr != nil {
+		require.EqualError(t, err, experr.Error())
+		return nil
+	}
+	require.NoError(t, err)
+	if !nodes.Equal(exp, got) {
+		want, err := uastyml.Marshal(exp)
+		require.NoError(t, err)
+		have, err := uastyml.Marshal(got)
+		require.NoError(t, err)
+		require.Equal(t, string(want), string(have), "edit: %+v", e)
+	}
+	return got
+}
+
+func TestReparse(t *testing.T) {
+	prev, err := Parse(reparseCode)
+	require.NoError(t, err)
+
+	// replace returns an edit that replaces the first occurrence of old with text
+	replace := func(old, text string) Edit {
+		i := strings.Index(reparseCode, old)
+		require.True(t, i >= 0, old)
+		return Edit{Offset: i, Length: len(old), Text: text}
+	}
+	cases := []struct {
+		name string
+		edit Edit
+	}{
+		{"call", replace("names)", "names, Point{1, 2}.Dist())")},
+		{"statements", replace("return p.X", "x := 1\n\t_ = x\n\n\treturn p.X")},
+		{"field", replace("\tX, Y int // coordinates\n", "")},
+		{"doc", replace("a greeting.", "a greeting\n// in English.")},
+		{"blank line", replace("\"hello\"\n\n", "\"hello\"\n")},
+		{"import", replace("\"strings\"\n", "\"strings\"\n\t\"os\"\n")},
+		{"package", replace("package main", "package other")},
+		{"resolve", replace("return x + undefined", "return x + helper(len(names))")},
+		{"new decl", Edit{Offset: len(reparseCode), Text: "\nfunc extra() { helper(1) }\n"}},
+		{"decl before imports", Edit{Offset: len("package main"), Text: "\nfunc (p *T) M() {}\n"}},
+		{"import after decls", Edit{Offset: len(reparseCode), Text: "\nimport \"os\"\n"}},
+		{"remove decl", replace("var names = []string{\"a\", \"b\"}\n", "")},
+		{"rename", replace("func helper", "func helper2")},
+		{"syntax error", replace("func main() {", "func main() {{")},
+		{"unterminated comment", replace("// print", "/* print")},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			requireReparse(t, reparseCode, prev, c.edit)
+		})
+	}
+
+	// declarations far from the edit are reused
+	got := requireReparse(t, reparseCode, prev, replace("return x + undefined", "return x"))
+	prevDecls := prev.(nodes.Object)["Decls"].(nodes.Array)
+	gotDecls := got.(nodes.Object)["Decls"].(nodes.Array)
+	require.Equal(t, len(prevDecls), len(gotDecls))
+	for i := range prevDecls[:len(prevDecls)-1] {
+		require.Equal(t, reflect.ValueOf(prevDecls[i]).Pointer(), reflect.ValueOf(gotDecls[i]).Pointer())
+	}
+}
+
+func TestReparseFixtures(t *testing.T) {
+	for _, name := range whiteList {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			data, err := ioutil.ReadFile(filepath.Join(fixtures, name))
+			require.NoError(t, err)
+			code := string(data)
+			prev, err := Parse(code)
+			require.NoError(t, err)
+			// insert an empty line and remove each line of the file in turn
+			for off := 0; off < len(code); {
+				n := strings.IndexByte(code[off:], '\n') + 1
+				if n == 0 {
+					n = len(code) - off
+				}
+				requireReparse(t, code, prev, Edit{Offset: off, Text: "\n"})
+				requireReparse(t, code, prev, Edit{Offset: off, Length: n})
+				off += n
+			}
+		})
+	}
+}
+
+// hugeCode returns the code of fixtures/json.go with all declarations except imports repeated n times.
+func hugeCode(t testing.TB, n int) string {
+	data, err := ioutil.ReadFile(filepath.Join(fixtures, "json.go"))
+	require.NoError(t, err)
+	code := string(data)
+	i := strings.Index(code, "\n)\n")
+	require.True(t, i > 0)
+	i += len("\n)\n")
+	return code[:i] + strings.Repeat(code[i:], n)
+}
+
+func TestParseContext(t *testing.T) {
+	code := hugeCode(t, 100)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := ParseContext(ctx, code)
+	require.Error(t, err)
+	var cerr *CanceledError
+	require.True(t, errors.As(err, &cerr), "%T", err)
+	require.True(t, errors.Is(err, context.Canceled))
+
+	f, fs, err := ParseString(code)
+	require.NoError(t, err)
+
+	// the context is canceled after the conversion starts, thus only the periodic check can stop it
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	_, err = ValueToNodeContext(cancelOnDone{ctx, cancel}, f, fs)
+	require.True(t, errors.As(err, &cerr), "%T", err)
+	require.True(t, errors.Is(err, context.Canceled))
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	_, err = NewDriver().Parse(cancelOnDone{ctx, cancel}, code)
+	require.True(t, errors.As(err, &cerr), "%T", err)
+}
+
+// cancelOnDone is a context that is canceled as soon as its Done channel is requested.
+type cancelOnDone struct {
+	context.Context
+	cancel context.CancelFunc
+}
+
+func (c cancelOnDone) Done() <-chan struct{} {
+	c.cancel()
+	return c.Context.Done()
+}
+
+func TestLimits(t *testing.T) {
+	json := hugeCode(t, 1)
+	nested := "package main\n\nvar x = " + strings.Repeat("(", 200) + "1" + strings.Repeat(")", 200) + "\n"
+
+	cases := []struct {
+		name  string
+		code  string
+		lim   Limits
+		limit string
+	}{
+		{"default", json, DefaultLimits, ""},
+		{"source bytes", json, Limits{MaxSourceBytes: 1000}, LimitSourceBytes},
+		{"nodes", json, Limits{MaxNodes: 1000}, LimitNodes},
+		{"nodes ok", json, Limits{MaxNodes: 10000}, ""},
+		{"depth", nested, Limits{MaxDepth: 100}, LimitDepth},
+		{"depth ok", nested, Limits{MaxDepth: 1000}, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := ParseWithOptions(c.code, Options{Limits: c.lim})
+			if c.limit == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.True(t, errors.Is(err, ErrLimitExceeded), "%v", err)
+			var lerr *LimitError
+			require.True(t, errors.As(err, &lerr))
+			require.Equal(t, c.limit, lerr.Limit)
+
+			// the driver does not report exceeded limits as syntax errors
+			_, err = NewDriverWithOptions(Options{Limits: c.lim}).Parse(context.Background(), c.code)
+			require.True(t, driver.ErrDriverFailure.Is(err), "%v", err)
+		})
+	}
+}
+
+func TestConverter(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join(fixtures, "*.go"))
+	require.NoError(t, err)
+	codes := make(map[string]string)
+	for _, name := range files {
+		data, err := ioutil.ReadFile(name)
+		require.NoError(t, err)
+		codes[filepath.Base(name)] = string(data)
+	}
+	// type parameters are converted via reflection by newer versions of Go
+	codes["generics"] = `package main
+
+type Pair[K comparable, V any] struct{ Key K; Value V }
+
+func Map[T, U any](s []T, fn func(T) U) (out []U) {
+	for _, v := range s {
+		out = append(out, fn(v))
+	}
+	return
+}
+
+var _ = Pair[string, int]{}
+`
+	for name, code := range codes {
+		t.Run(name, func(t *testing.T) {
+			f, fs, err := ParseString(code)
+			if err != nil {
+				t.Skip(err)
+			}
+			got, err := ValueToNode(f, fs)
+			require.NoError(t, err)
+			c := &converter{ctx: context.Background(), fs: fs}
+			exp, err := c.toNode(reflect.ValueOf(f))
+			require.NoError(t, err)
+			require.True(t, nodes.Equal(exp, got))
+		})
+	}
+}
+
+func TestNodeToASTGenerics(t *testing.T) {
+	const code = `package main
+
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+var _ = Pair[string, int]{}
+`
+	ast, err := Parse(code)
+	if err != nil {
+		t.Skip(err)
+	}
+	// node types added to go/ast after the minimum supported release are registered separately
+	got, err := nodeToCode(ast)
+	require.NoError(t, err)
+	require.Equal(t, code, got)
+}
+
+func TestObjectToAST(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join(fixtures, "*.go"))
+	require.NoError(t, err)
+	for _, name := range files {
+		name := name
+		t.Run(filepath.Base(name), func(t *testing.T) {
+			data, err := ioutil.ReadFile(name)
+			require.NoError(t, err)
+			n, err := Parse(string(data))
+			if err != nil {
+				t.Skip(err)
+			}
+			// every object converted without reflection must be equal to the one set via reflection
+			nodes.WalkPreOrder(n, func(n nodes.Node) bool {
+				o, ok := n.(nodes.Object)
+				if !ok {
+					return true
+				}
+				got, ok := objectToAST(o)
+				if !ok {
+					return true
+				}
+				typ := uast.TypeOf(o)
+				exp := reflect.New(typeNameToType[typ]).Elem()
+				for k, v := range o {
+					setField(exp, typ, k, v)
+				}
+				require.Equal(t, exp.Addr().Interface(), got, typ)
+				return true
+			})
+		})
+	}
+}
+
+func TestParseStream(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join(fixtures, "*.go"))
+	require.NoError(t, err)
+	opts := Options{Captures: true, Metrics: true, Calls: true, Scopes: true, CompactPositions: true, Limits: DefaultLimits}
+	for _, name := range files {
+		name := name
+		t.Run(filepath.Base(name), func(t *testing.T) {
+			data, err := ioutil.ReadFile(name)
+			require.NoError(t, err)
+			exp, err := ParseWithOptions(string(data), opts)
+			if err != nil {
+				t.Skip(err)
+			}
+			var (
+				a     Assembler
+				parts int
+			)
+			err = ParseStream(context.Background(), string(data), opts, func(n nodes.Node) error {
+				parts++
+				return a.Add(n)
+			})
+			require.NoError(t, err)
+			got, err := a.Node()
+			require.NoError(t, err)
+			decls, _ := got.(nodes.Object)["Decls"].(nodes.Array)
+			require.Equal(t, 1+len(decls), parts)
+			require.True(t, nodes.Equal(exp, got))
+		})
+	}
+}
+
+func TestParseStreamErrors(t *testing.T) {
+	const code = "package main\n\nfunc a() {}\n\nfunc b() {}\n"
+	ctx := context.Background()
+
+	errStop := errors.New("stop")
+	var parts int
+	err := ParseStream(ctx, code, Options{}, func(n nodes.Node) error {
+		if parts++; parts == 2 {
+			return errStop
+		}
+		return nil
+	})
+	require.Equal(t, errStop, err)
+	require.Equal(t, 2, parts)
+
+	err = ParseStream(ctx, code, Options{Limits: Limits{MaxNodes: 10}}, func(n nodes.Node) error { return nil })
+	require.True(t, errors.Is(err, ErrLimitExceeded), "%v", err)
+
+	err = ParseStream(ctx, code, Options{IDs: true}, func(n nodes.Node) error { return nil })
+	require.Error(t, err)
+
+	var a Assembler
+	_, err = a.Node()
+	require.Error(t, err)
+	require.Error(t, a.Add(nodes.Object{uast.KeyType: nodes.String("FuncDecl")}))
+
+	var all []nodes.Node
+	err = ParseStream(ctx, code, Options{}, func(n nodes.Node) error {
+		all = append(all, n)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	require.NoError(t, a.Add(all[0]))
+	require.NoError(t, a.Add(all[1]))
+	_, err = a.Node()
+	require.Error(t, err)
+	require.NoError(t, a.Add(all[2]))
+	require.Error(t, a.Add(all[2]))
+}
+
+func TestCompactPositions(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join(fixtures, "*.go"))
+	require.NoError(t, err)
+	for _, name := range files {
+		name := name
+		t.Run(filepath.Base(name), func(t *testing.T) {
+			data, err := ioutil.ReadFile(name)
+			require.NoError(t, err)
+			exp, err := Parse(string(data))
+			if err != nil {
+				t.Skip(err)
+			}
+			got, err := ParseWithOptions(string(data), Options{CompactPositions: true})
+			require.NoError(t, err)
+			require.True(t, nodes.Count(got, nodes.KindsAny) < nodes.Count(exp, nodes.KindsAny))
+			require.NoError(t, ExpandPositions(got))
+
+			// only valid start and end positions are restored
+			nodes.WalkPreOrder(exp, func(n nodes.Node) bool {
+				obj, ok := n.(nodes.Object)
+				if !ok {
+					return true
+				}
+				ps, ok := obj[uast.KeyPos].(nodes.Object)
+				if !ok {
+					return true
+				}
+				for k, v := range ps {
+					if k == uast.KeyType {
+						continue
+					}
+					if p := uast.AsPosition(v.(nodes.Object)); (k != uast.KeyStart && k != uast.KeyEnd) || p.Line == 0 {
+						delete(ps, k)
+					}
+				}
+				return true
+			})
+			require.True(t, nodes.Equal(exp, got))
+		})
+	}
+	require.Error(t, ExpandPositions(nodes.Object{uast.KeyType: nodes.String("File")}))
+}
+
+func TestLean(t *testing.T) {
+	files, err := selectFiles()
+	require.NoError(t, err)
+	wl, err := getWhiteListMap()
+	require.NoError(t, err)
+
+	opts := Options{Captures: true, Metrics: true, Calls: true}
+	for _, f := range files {
+		fBase := filepath.Base(f)
+		t.Run(fBase, func(t *testing.T) {
+			data, err := ioutil.ReadFile(f)
+			require.NoError(t, err)
+			exp, err := ParseWithOptions(string(data), opts)
+			if err != nil {
+				t.Skip(err)
+			}
+			lean := opts
+			lean.Lean = true
+			got, err := ParseWithOptions(string(data), lean)
+			require.NoError(t, err)
+			require.True(t, nodes.Count(got, nodes.KindsAny) < nodes.Count(exp, nodes.KindsAny))
+
+			if _, ok := wl[fBase]; ok {
+				expCode, err := nodeToCode(exp)
+				require.NoError(t, err)
+				actCode, err := nodeToCode(got)
+				require.NoError(t, err)
+				require.Equal(t, expCode, actCode)
+			}
+			RestoreLean(got)
+			require.True(t, nodes.Equal(exp, got))
+
+			// fields are restored before positions
+			lean.CompactPositions = true
+			got, err = ParseWithOptions(string(data), lean)
+			require.NoError(t, err)
+			RestoreLean(got)
+			require.NoError(t, ExpandPositions(got))
+			compact := opts
+			compact.CompactPositions = true
+			exp, err = ParseWithOptions(string(data), compact)
+			require.NoError(t, err)
+			require.NoError(t, ExpandPositions(exp))
+			require.True(t, nodes.Equal(exp, got))
+		})
+	}
+}
+
+func TestNodeToASTErr(t *testing.T) {
+	ident := func(name nodes.Node) nodes.Object {
+		return nodes.Object{uast.KeyType: nodes.String("Ident"), "Name": name}
+	}
+	file := func(decls ...nodes.Node) nodes.Object {
+		return nodes.Object{
+			uast.KeyType: nodes.String("File"),
+			"Name":       ident(nodes.String("main")),
+			"Decls":      nodes.Array(decls),
+		}
+	}
+	funcDecl := func(name nodes.Node) nodes.Object {
+		return nodes.Object{uast.KeyType: nodes.String("FuncDecl"), "Name": name}
+	}
+	cases := []struct {
+		name string
+		node nodes.Node
+		exp  ConvertError
+	}{
+		{
+			name: "not an object",
+			node: nodes.Array{},
+			exp:  ConvertError{Reason: "expected an object, got Array"},
+		},
+		{
+			name: "unknown type",
+			node: file(funcDecl(ident(nodes.String("f"))), nodes.Object{uast.KeyType: nodes.String("Foo")}),
+			exp:  ConvertError{Path: "Decls[1]", Type: "Foo", Reason: `unsupported node type "Foo"`},
+		},
+		{
+			name: "unknown field",
+			node: file(funcDecl(nodes.Object{uast.KeyType: nodes.String("Ident"), "Foo": nodes.String("f")})),
+			exp:  ConvertError{Path: "Decls[0].Name", Type: "Ident", Reason: `field "Foo" not found`},
+		},
+		{
+			name: "wrong value",
+			node: file(funcDecl(ident(nodes.Int(1)))),
+			exp:  ConvertError{Path: "Decls[0].Name", Type: "Ident"},
+		},
+		{
+			name: "wrong node type",
+			node: file(funcDecl(nodes.Object{uast.KeyType: nodes.String("BlockStmt")})),
+			exp:  ConvertError{Path: "Decls[0]", Type: "FuncDecl"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := NodeToASTErr(c.node)
+			require.Error(t, err)
+			e, ok := err.(*ConvertError)
+			require.True(t, ok, "%T: %v", err, err)
+			require.Equal(t, c.exp.Path, e.Path)
+			require.Equal(t, c.exp.Type, e.Type)
+			if c.exp.Reason != "" {
+				require.Equal(t, c.exp.Reason, e.Reason)
+			}
+			require.Panics(t, func() { NodeToAST(c.node) })
+		})
+	}
+
+	n, err := NodeToASTErr(file(funcDecl(ident(nodes.String("f")))))
+	require.NoError(t, err)
+	require.Equal(t, "f", n.(*ast.File).Decls[0].(*ast.FuncDecl).Name.Name)
+}
+
+// TestNodeToASTMutations checks that NodeToASTErr does not panic on trees from fixtures
+// with fields replaced by values of unexpected kinds.
+func TestNodeToASTMutations(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join(fixtures, "*.go.native"))
+	require.NoError(t, err)
+	values := []nodes.Node{
+		nil,
+		nodes.String("x"),
+		nodes.Int(1),
+		nodes.Bool(true),
+		nodes.Array{nodes.String("x")},
+		nodes.Array{nil},
+		nodes.Object{},
+		nodes.Object{uast.KeyType: nodes.String("Foo")},
+		nodes.Object{uast.KeyType: nodes.String("Ident"), "Name": nodes.String("x")},
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for _, name := range files {
+		name := name
+		t.Run(filepath.Base(name), func(t *testing.T) {
+			data, err := ioutil.ReadFile(name)
+			require.NoError(t, err)
+			root, err := uastyml.Unmarshal(data)
+			require.NoError(t, err)
+			_, err = NodeToASTErr(root)
+			require.NoError(t, err)
+
+			var objs []nodes.Object
+			nodes.WalkPreOrder(root, func(n nodes.Node) bool {
+				if o, ok := n.(nodes.Object); ok && uast.TypeOf(o) != "" && !strings.HasPrefix(uast.TypeOf(o), "uast:") {
+					objs = append(objs, o)
+				}
+				return true
+			})
+			for i := 0; i < 20; i++ {
+				o := objs[rnd.Intn(len(objs))]
+				keys := o.Keys()
+				k := keys[rnd.Intn(len(keys))]
+				old, had := o[k]
+				// only the mutated node is converted, since converting the whole tree takes longer
+				for _, v := range values {
+					o[k] = v
+					_, err = NodeToASTErr(o)
+					if _, ok := err.(*ConvertError); err != nil && !ok {
+						t.Fatalf("unexpected error: %v", err)
+					}
+				}
+				o["Unknown"] = nodes.String("x")
+				_, err = NodeToASTErr(o)
+				_, ok := err.(*ConvertError)
+				require.True(t, ok, "%T: %v", err, err)
+				delete(o, "Unknown")
+				if had {
+					o[k] = old
+				} else {
+					delete(o, k)
+				}
+			}
+		})
+	}
+}
+
+func TestNodeToASTVariadicCall(t *testing.T) {
+	const code = `package main
+
+func f(args ...int) {
+	f(args...)
+	f(args)
+}
+`
+	node, err := Parse(code)
+	require.NoError(t, err)
+	got, err := Print(node)
+	require.NoError(t, err)
+	require.Equal(t, code, got)
+
+	// positions, including the one of the ellipsis, are not converted to the AST
+	f := NodeToAST(node).(*ast.File)
+	call := f.Decls[0].(*ast.FuncDecl).Body.List[0].(*ast.ExprStmt).X.(*ast.CallExpr)
+	require.Equal(t, token.NoPos, call.Ellipsis)
+}
+
+// BenchmarkValueToNode compares the converter with the conversion that relies on reflection only.
+func BenchmarkValueToNode(b *testing.B) {
+	for _, name := range []string{"json.go", "bench_worktree.go"} {
+		data, err := ioutil.ReadFile(filepath.Join(fixtures, name))
+		require.NoError(b, err)
+		f, fs, err := ParseString(string(data))
+		require.NoError(b, err)
+
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, err := ValueToNode(f, fs)
+				require.NoError(b, err)
+			}
+		})
+		b.Run(name+"/reflect", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				c := &converter{ctx: context.Background(), fs: fs}
+				_, err := c.toNode(reflect.ValueOf(f))
+				require.NoError(b, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package golang
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+)
+
+// KeyID is a reserved key that stores an identifier of a node (see Options.IDs).
+// It is preserved by the normalizer, thus the same ID refers to the same node in native,
+// annotated and semantic trees. IDs are unique within a tree: nodes that the normalizer
+// duplicates only keep the ID on one of the copies.
+const KeyID = "@id"
+
+// nodeID returns an identifier of a native node that is derived from its type and the source span.
+func nodeID(typ string, start, end uint32) string {
+	return typ + ":" + strconv.FormatUint(uint64(start), 10) + ":" + strconv.FormatUint(uint64(end), 10)
+}
+
+// astNodeID returns an identifier of a native node that corresponds to a given Go AST node.
+func astNodeID(typ string, n ast.Node, fs *token.FileSet) string {
+	return nodeID(typ, convertPosition(n.Pos(), fs).Offset, convertPosition(n.End(), fs).Offset)
+}
+
+// assignIDs sets KeyID on all nodes of a native tree. The tree is modified in place.
+//
+// IDs are derived from the type and the span of the node (see nodeID). If there are multiple
+// nodes of the same type with the same span, the ones that follow the first one in pre-order
+// get a "#N" suffix, where N is the number of the node, starting from 2.
+func assignIDs(root nodes.Node) {
+	seen := make(map[string]int)
+	var walk func(n nodes.Node)
+	walk = func(n nodes.Node) {
+		switch n := n.(type) {
+		case nodes.Array:
+			for _, v := range n {
+				walk(v)
+			}
+		case nodes.Object:
+			if typ := uast.TypeOf(n); typ != "" {
+				var start, end uint32
+				ps := uast.PositionsOf(n)
+				if p := ps.Start(); p != nil {
+					start = p.Offset
+				}
+				if p := ps.End(); p != nil {
+					end = p.Offset
+				}
+				id := nodeID(typ, start, end)
+				seen[id]++
+				if cnt := seen[id]; cnt > 1 {
+					id += "#" + strconv.Itoa(cnt)
+				}
+				n[KeyID] = nodes.String(id)
+			}
+			// keys are sorted to make IDs of duplicate nodes deterministic
+			for _, k := range n.Keys() {
+				if k != uast.KeyPos {
+					walk(n[k])
+				}
+			}
+		}
+	}
+	walk(root)
+}
@@ -0,0 +1,376 @@
+package golang
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+)
+
+// Edit is a change of the source code: Length bytes starting at Offset are replaced with Text.
+type Edit struct {
+	Offset int
+	Length int
+	Text   string
+}
+
+// Apply returns the source code after the edit.
+func (e Edit) Apply(code string) (string, error) {
+	if e.Offset < 0 || e.Length < 0 || e.Offset+e.Length > len(code) {
+		return "", fmt.Errorf("edit [%d, %d) is out of range of the source code of %d bytes",
+			e.Offset, e.Offset+e.Length, len(code))
+	}
+	return code[:e.Offset] + e.Text + code[e.Offset+e.Length:], nil
+}
+
+// Reparse returns a native tree of the source code after the edit, given the source code before
+// the edit and its native tree returned by Parse. The result is the same as of Parse on the edited code.
+//
+// Only top-level declarations affected by the edit are parsed again. Other declarations are reused:
+// the ones before the edit are shared with prev, and the ones after it are copied with positions shifted.
+// Declarations are considered affected if they or their doc comments are within a line from the edit.
+// The whole file is parsed again if the edit changes the set of package-level names, if it moves
+// imports after other declarations, or if the code cannot be parsed. Optional analyses (see Options) are not supported.
+func Reparse(prevCode string, prev nodes.Node, e Edit) (nodes.Node, error) {
+	code, err := e.Apply(prevCode)
+	if err != nil {
+		return nil, err
+	}
+	file, ok := prev.(nodes.Object)
+	if !ok || uast.TypeOf(file) != "File" {
+		return Parse(code)
+	}
+	n, ok := reparse(prevCode, code, file, e)
+	if !ok {
+		return Parse(code)
+	}
+	return n, nil
+}
+
+// declSpan is a top-level declaration of the previous tree with its extent, including the doc comment.
+type declSpan struct {
+	node       nodes.Node
+	start, end int
+	// lines of the start and the end of the extent
+	first, last int
+}
+
+// reparse implements Reparse. It returns false if the whole file must be parsed again.
+func reparse(prevCode, code string, file nodes.Object, e Edit) (nodes.Node, bool) {
+	decls, _ := file["Decls"].(nodes.Array)
+	spans := make([]declSpan, 0, len(decls))
+	for _, d := range decls {
+		s, ok := spanOf(d)
+		if !ok {
+			return nil, false
+		}
+		spans = append(spans, s)
+	}
+	// lines of the edit in the previous source code
+	editFirst := 1 + strings.Count(prevCode[:e.Offset], "\n")
+	editLast := editFirst + strings.Count(prevCode[e.Offset:e.Offset+e.Length], "\n")
+
+	// spans[:i] end on lines before the edit, spans[j:] start on lines after the line that follows the edit;
+	// the latter restriction makes sure that the edit cannot add a doc comment to reused declarations
+	i := 0
+	for i < len(spans) && spans[i].last < editFirst {
+		i++
+	}
+	j := len(spans)
+	for j > i && spans[j-1].first > editLast+1 {
+		j--
+	}
+	sh := shift{
+		offset: len(e.Text) - e.Length,
+		line:   strings.Count(e.Text, "\n") - (editLast - editFirst),
+	}
+	before, after := spans[:i], spans[j:]
+
+	// reused declarations are replaced with spaces, leaving positions of the remaining code intact
+	buf := []byte(code)
+	blank := func(start, end int) {
+		for k := start; k < end; k++ {
+			if buf[k] != '\n' {
+				buf[k] = ' '
+			}
+		}
+	}
+	for _, s := range before {
+		blank(s.start, s.end)
+	}
+	for _, s := range after {
+		blank(s.start+sh.offset, s.end+sh.offset)
+	}
+	f, fs, err := ParseString(string(buf))
+	if err != nil {
+		return nil, false
+	}
+	nn, err := ValueToNode(reflect.ValueOf(f), fs)
+	if err != nil {
+		return nil, false
+	}
+	out, ok := nn.(nodes.Object)
+	if !ok {
+		return nil, false
+	}
+	changed, _ := out["Decls"].(nodes.Array)
+
+	// package-level names resolve identifiers in all declarations
+	var old []string
+	for _, s := range spans[i:j] {
+		old = declNames(s.node, old)
+	}
+	var names []string
+	for _, d := range changed {
+		names = declNames(d, names)
+	}
+	if !sameNames(old, names) {
+		return nil, false
+	}
+	reused := make(map[string]struct{})
+	for _, s := range before {
+		for _, name := range declNames(s.node, nil) {
+			reused[name] = struct{}{}
+		}
+	}
+	for _, s := range after {
+		for _, name := range declNames(s.node, nil) {
+			reused[name] = struct{}{}
+		}
+	}
+
+	// inReused reports if the node of the previous tree is a part of reused declarations
+	inReused := func(n nodes.Node) (bool, bool) {
+		off, ok := startOffset(n)
+		if !ok {
+			return false, false
+		}
+		for _, s := range before {
+			if off >= s.start && off < s.end {
+				return true, false
+			}
+		}
+		for _, s := range after {
+			if off >= s.start && off < s.end {
+				return true, true
+			}
+		}
+		return false, false
+	}
+
+	var decl nodes.Array
+	for _, s := range before {
+		decl = append(decl, s.node)
+	}
+	decl = append(decl, changed...)
+	for _, s := range after {
+		decl = append(decl, sh.apply(s.node))
+	}
+	// reused declarations are not parsed together with the changed ones, thus the parser
+	// cannot check that imports still precede other declarations
+	if !importsFirst(decl) {
+		return nil, false
+	}
+	out["Decls"] = nilIfEmpty(decl)
+
+	// comments inside reused declarations are taken from the previous tree, the rest are parsed again
+	var comments nodes.Array
+	prevComments, _ := file["Comments"].(nodes.Array)
+	for _, c := range prevComments {
+		if ok, shifted := inReused(c); ok {
+			if shifted {
+				c = sh.apply(c)
+			}
+			comments = append(comments, c)
+		}
+	}
+	newComments, _ := out["Comments"].(nodes.Array)
+	comments = append(comments, newComments...)
+	sort.SliceStable(comments, func(a, b int) bool {
+		oa, _ := startOffset(comments[a])
+		ob, _ := startOffset(comments[b])
+		return oa < ob
+	})
+	out["Comments"] = nilIfEmpty(comments)
+
+	var imports nodes.Array
+	for _, d := range decl {
+		d, _ := d.(nodes.Object)
+		if uast.TypeOf(d) != "GenDecl" || d["Tok"] != nodes.String("import") {
+			continue
+		}
+		specs, _ := d["Specs"].(nodes.Array)
+		for _, s := range specs {
+			imports = append(imports, s.Clone())
+		}
+	}
+	out["Imports"] = nilIfEmpty(imports)
+
+	// identifiers of parsed declarations that refer to reused declarations are resolved in the whole file;
+	// the order of the list follows the order of declarations
+	var unresolved nodes.Array
+	prevUnresolved, _ := file["Unresolved"].(nodes.Array)
+	newUnresolved, _ := out["Unresolved"].(nodes.Array)
+	var tail nodes.Array
+	for _, id := range prevUnresolved {
+		ok, shifted := inReused(id)
+		switch {
+		case !ok:
+		case shifted:
+			tail = append(tail, sh.apply(id))
+		default:
+			unresolved = append(unresolved, id)
+		}
+	}
+	for _, id := range newUnresolved {
+		name, _ := id.(nodes.Object)["Name"].(nodes.String)
+		if _, ok := reused[string(name)]; !ok {
+			unresolved = append(unresolved, id)
+		}
+	}
+	unresolved = append(unresolved, tail...)
+	out["Unresolved"] = nilIfEmpty(unresolved)
+
+	// the end of the file is the end of the last declaration
+	if len(decl) != 0 {
+		pos := uast.PositionsOf(out)
+		if end := uast.PositionsOf(decl[len(decl)-1]).End(); pos != nil && end != nil {
+			pos[uast.KeyEnd] = *end
+			out[uast.KeyPos] = pos.ToObject()
+		}
+	}
+	return out, true
+}
+
+// spanOf returns the extent of a top-level declaration.
+func spanOf(d nodes.Node) (declSpan, bool) {
+	pos := uast.PositionsOf(d)
+	start, end := pos.Start(), pos.End()
+	if start == nil || end == nil {
+		return declSpan{}, false
+	}
+	s := declSpan{
+		node: d, start: int(start.Offset), end: int(end.Offset),
+		first: int(start.Line), last: int(end.Line),
+	}
+	if doc, ok := d.(nodes.Object)["Doc"].(nodes.Object); ok {
+		if p := uast.PositionsOf(doc).Start(); p != nil {
+			s.start, s.first = int(p.Offset), int(p.Line)
+		}
+	}
+	return s, true
+}
+
+func startOffset(n nodes.Node) (int, bool) {
+	p := uast.PositionsOf(n).Start()
+	if p == nil {
+		return 0, false
+	}
+	return int(p.Offset), true
+}
+
+// declNames appends names that a top-level declaration adds to the package scope.
+func declNames(d nodes.Node, names []string) []string {
+	obj, _ := d.(nodes.Object)
+	ident := func(n nodes.Node) {
+		id, _ := n.(nodes.Object)
+		if name, ok := id["Name"].(nodes.String); ok && name != "_" {
+			names = append(names, string(name))
+		}
+	}
+	switch uast.TypeOf(obj) {
+	case "FuncDecl":
+		if obj["Recv"] == nil {
+			if id, _ := obj["Name"].(nodes.Object); id["Name"] != nodes.String("init") {
+				ident(id)
+			}
+		}
+	case "GenDecl":
+		specs, _ := obj["Specs"].(nodes.Array)
+		for _, s := range specs {
+			s, _ := s.(nodes.Object)
+			switch uast.TypeOf(s) {
+			case "TypeSpec":
+				ident(s["Name"])
+			case "ValueSpec":
+				ids, _ := s["Names"].(nodes.Array)
+				for _, id := range ids {
+					ident(id)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// importsFirst reports if import declarations precede all other declarations.
+func importsFirst(decls nodes.Array) bool {
+	other := false
+	for _, d := range decls {
+		d, _ := d.(nodes.Object)
+		if uast.TypeOf(d) != "GenDecl" || d["Tok"] != nodes.String("import") {
+			other = true
+		} else if other {
+			return false
+		}
+	}
+	return true
+}
+
+func sameNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func nilIfEmpty(arr nodes.Array) nodes.Node {
+	if len(arr) == 0 {
+		return nil
+	}
+	return arr
+}
+
+// shift is a change of positions of the code that follows an edit. Columns are not changed,
+// since reused declarations never start on the last line of the edit.
+type shift struct {
+	offset, line int
+}
+
+// apply returns a copy of the subtree with all valid positions shifted.
+func (sh shift) apply(n nodes.Node) nodes.Node {
+	switch n := n.(type) {
+	case nodes.Array:
+		arr := make(nodes.Array, len(n))
+		for i, v := range n {
+			arr[i] = sh.apply(v)
+		}
+		return arr
+	case nodes.Object:
+		if uast.TypeOf(n) == uast.TypePosition {
+			p := uast.AsPosition(n)
+			if p == nil || p.Line == 0 {
+				return n
+			}
+			p.Offset = uint32(int(p.Offset) + sh.offset)
+			p.Line = uint32(int(p.Line) + sh.line)
+			return p.ToObject()
+		}
+		obj := make(nodes.Object, len(n))
+		for k, v := range n {
+			obj[k] = sh.apply(v)
+		}
+		return obj
+	}
+	return n
+}
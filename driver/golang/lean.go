@@ -0,0 +1,165 @@
+package golang
+
+import (
+	"reflect"
+
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+)
+
+// derivedPos describes a position of a field of an AST node that usually can be derived
+// from the start or the end position of the node.
+type derivedPos struct {
+	// key is either uast.KeyStart or uast.KeyEnd
+	key string
+	// delta is added to the offset and the column of the position
+	delta int
+}
+
+var (
+	atStart          = derivedPos{key: uast.KeyStart}
+	atEnd            = derivedPos{key: uast.KeyEnd}
+	beforeEnd        = derivedPos{key: uast.KeyEnd, delta: -1}
+	derivedPositions = map[string]map[string]derivedPos{
+		"ArrayType":      {"Lbrack": atStart},
+		"BadDecl":        {"From": atStart, "To": atEnd},
+		"BadExpr":        {"From": atStart, "To": atEnd},
+		"BadStmt":        {"From": atStart, "To": atEnd},
+		"BasicLit":       {"ValuePos": atStart},
+		"BlockStmt":      {"Lbrace": atStart, "Rbrace": beforeEnd},
+		"BranchStmt":     {"TokPos": atStart},
+		"CallExpr":       {"Rparen": beforeEnd},
+		"CaseClause":     {"Case": atStart},
+		"ChanType":       {"Begin": atStart},
+		"CommClause":     {"Case": atStart},
+		"Comment":        {"Slash": atStart},
+		"CompositeLit":   {"Rbrace": beforeEnd},
+		"DeferStmt":      {"Defer": atStart},
+		"Ellipsis":       {"Ellipsis": atStart},
+		"EmptyStmt":      {"Semicolon": atStart},
+		"FieldList":      {"Opening": atStart, "Closing": beforeEnd},
+		"File":           {"Package": atStart},
+		"ForStmt":        {"For": atStart},
+		"FuncType":       {"Func": atStart},
+		"GenDecl":        {"TokPos": atStart, "Rparen": beforeEnd},
+		"GoStmt":         {"Go": atStart},
+		"Ident":          {"NamePos": atStart},
+		"IfStmt":         {"If": atStart},
+		"ImportSpec":     {"EndPos": atEnd},
+		"IndexExpr":      {"Rbrack": beforeEnd},
+		"IndexListExpr":  {"Rbrack": beforeEnd},
+		"InterfaceType":  {"Interface": atStart},
+		"MapType":        {"Map": atStart},
+		"ParenExpr":      {"Lparen": atStart, "Rparen": beforeEnd},
+		"RangeStmt":      {"For": atStart},
+		"ReturnStmt":     {"Return": atStart},
+		"SelectStmt":     {"Select": atStart},
+		"SliceExpr":      {"Rbrack": beforeEnd},
+		"StarExpr":       {"Star": atStart},
+		"StructType":     {"Struct": atStart},
+		"SwitchStmt":     {"Switch": atStart},
+		"TypeAssertExpr": {"Rparen": beforeEnd},
+		"TypeSwitchStmt": {"Switch": atStart},
+		"UnaryExpr":      {"OpPos": atStart},
+	}
+)
+
+// derive returns the position derived from the start or the end position, or nil if it cannot be derived.
+func (d derivedPos) derive(pos nodes.Object) nodes.Object {
+	p, _ := pos[d.key].(nodes.Object)
+	off, ok1 := p["offset"].(nodes.Uint)
+	line, ok2 := p["line"].(nodes.Uint)
+	col, ok3 := p["col"].(nodes.Uint)
+	if !ok1 || !ok2 || !ok3 || line == 0 {
+		return nil
+	}
+	return nodes.Object{
+		uast.KeyType: nodes.String(uast.TypePosition),
+		"offset":     nodes.Uint(int(off) + d.delta),
+		"line":       line,
+		"col":        nodes.Uint(int(col) + d.delta),
+	}
+}
+
+// isASTType reports if the object is a native node that corresponds to an AST node. Objects created
+// by optional analyses are not.
+func isASTType(obj nodes.Object) bool {
+	t, ok := typeNameToType[uast.TypeOf(obj)]
+	return ok && t.Kind() == reflect.Struct && reflect.PtrTo(t).Implements(NodeType)
+}
+
+// leanNodes removes nil fields and positions of fields that can be derived from start and end positions
+// from all native nodes that correspond to AST nodes (see Options.Lean). The tree is modified in place.
+func leanNodes(root nodes.Node) {
+	switch n := root.(type) {
+	case nodes.Array:
+		for _, v := range n {
+			leanNodes(v)
+		}
+	case nodes.Object:
+		if !isASTType(n) {
+			for _, v := range n {
+				leanNodes(v)
+			}
+			return
+		}
+		for k, v := range n {
+			if v == nil {
+				delete(n, k)
+				continue
+			}
+			leanNodes(v)
+		}
+		pos, ok := n[uast.KeyPos].(nodes.Object)
+		if !ok {
+			return
+		}
+		for k, d := range derivedPositions[uast.TypeOf(n)] {
+			if p, ok := pos[k]; ok && nodes.Equal(p, d.derive(pos)) {
+				delete(pos, k)
+			}
+		}
+	}
+}
+
+// RestoreLean restores fields removed from a native tree returned with Options.Lean, so the tree
+// is the same as the one returned without the option. The tree is modified in place.
+// Trees with compact positions (see Options.CompactPositions) must be restored before ExpandPositions.
+func RestoreLean(root nodes.Node) {
+	switch n := root.(type) {
+	case nodes.Array:
+		for _, v := range n {
+			RestoreLean(v)
+		}
+	case nodes.Object:
+		for _, v := range n {
+			RestoreLean(v)
+		}
+		if !isASTType(n) {
+			return
+		}
+		typ := uast.TypeOf(n)
+		t := typeNameToType[typ]
+		for i := 0; i < t.NumField(); i++ {
+			switch f := t.Field(i); f.Type {
+			case PosType, ScopeType, ObjectType:
+			default:
+				if _, ok := n[f.Name]; !ok {
+					n[f.Name] = nil
+				}
+			}
+		}
+		pos, ok := n[uast.KeyPos].(nodes.Object)
+		if !ok {
+			return
+		}
+		for k, d := range derivedPositions[typ] {
+			if _, ok := pos[k]; ok {
+				continue
+			}
+			if p := d.derive(pos); p != nil {
+				pos[k] = p
+			}
+		}
+	}
+}
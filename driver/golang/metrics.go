@@ -0,0 +1,239 @@
+package golang
+
+import (
+	"go/ast"
+	"go/token"
+
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+)
+
+const (
+	// typeMetrics is a type of native nodes that store metrics of a function.
+	typeMetrics = "Metrics"
+
+	// fieldMetrics is a field of FuncDecl and FuncLit set by the metrics analysis.
+	fieldMetrics = "Metrics"
+)
+
+// funcMetrics is a set of complexity metrics of a single function.
+type funcMetrics struct {
+	// cyclomatic is the McCabe complexity: the number of decision points plus one.
+	cyclomatic int
+	// cognitive is the cognitive complexity as defined by SonarSource.
+	cognitive int
+	// nesting is the maximal nesting depth of control-flow statements.
+	nesting int
+	// statements is the number of statements, not counting blocks, labels and case clauses.
+	statements int
+	// params is the number of parameters, not counting the receiver.
+	params int
+}
+
+// metrics computes complexity metrics of each function declaration and function literal in the file.
+// The result is a set of fields for each function, indexed by its position.
+//
+// Bodies of nested function literals are not a part of the enclosing function; they have their own metrics.
+func metrics(f *ast.File) map[token.Pos]nodes.Object {
+	out := make(map[token.Pos]nodes.Object)
+	ast.Inspect(f, func(n ast.Node) bool {
+		var (
+			w    = &metricsWalker{seen: make(map[*ast.BinaryExpr]struct{})}
+			typ  *ast.FuncType
+			body *ast.BlockStmt
+		)
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			if n.Recv == nil {
+				w.decl = n
+			}
+			typ, body = n.Type, n.Body
+		case *ast.FuncLit:
+			typ, body = n.Type, n.Body
+		default:
+			return true
+		}
+		if typ.Params != nil {
+			for _, p := range typ.Params.List {
+				if len(p.Names) == 0 {
+					w.m.params++
+				} else {
+					w.m.params += len(p.Names)
+				}
+			}
+		}
+		w.m.cyclomatic = 1
+		if body != nil {
+			ast.Walk(w, body)
+		}
+		out[n.Pos()] = nodes.Object{
+			fieldMetrics: w.m.toNode(),
+		}
+		return true
+	})
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func (m funcMetrics) toNode() nodes.Object {
+	return nodes.Object{
+		uast.KeyType: nodes.String(typeMetrics),
+		"Cyclomatic": nodes.Int(m.cyclomatic),
+		"Cognitive":  nodes.Int(m.cognitive),
+		"MaxNesting": nodes.Int(m.nesting),
+		"Statements": nodes.Int(m.statements),
+		"Params":     nodes.Int(m.params),
+	}
+}
+
+// metricsWalker computes metrics of a function body.
+type metricsWalker struct {
+	// decl is set for functions without a receiver to detect recursive calls
+	decl    *ast.FuncDecl
+	m       funcMetrics
+	nesting int
+	// seen is a set of logical operators that were already counted as a part of a sequence
+	seen map[*ast.BinaryExpr]struct{}
+}
+
+func (w *metricsWalker) Visit(n ast.Node) ast.Visitor {
+	switch n.(type) {
+	case *ast.BlockStmt, *ast.LabeledStmt, *ast.CaseClause, *ast.CommClause:
+	case ast.Stmt:
+		w.m.statements++
+	}
+	switch n := n.(type) {
+	case *ast.FuncLit:
+		return nil
+	case *ast.IfStmt:
+		w.ifStmt(n, false)
+		return nil
+	case *ast.ForStmt:
+		w.m.cyclomatic++
+		w.structural()
+		w.walk(n.Init)
+		w.walk(n.Cond)
+		w.walk(n.Post)
+		w.nested(n.Body)
+		return nil
+	case *ast.RangeStmt:
+		w.m.cyclomatic++
+		w.structural()
+		w.walk(n.Key)
+		w.walk(n.Value)
+		w.walk(n.X)
+		w.nested(n.Body)
+		return nil
+	case *ast.SwitchStmt:
+		w.structural()
+		w.walk(n.Init)
+		w.walk(n.Tag)
+		w.nested(n.Body)
+		return nil
+	case *ast.TypeSwitchStmt:
+		w.structural()
+		w.walk(n.Init)
+		w.walk(n.Assign)
+		w.nested(n.Body)
+		return nil
+	case *ast.SelectStmt:
+		w.structural()
+		w.nested(n.Body)
+		return nil
+	case *ast.CaseClause:
+		if n.List != nil {
+			w.m.cyclomatic++
+		}
+	case *ast.CommClause:
+		if n.Comm != nil {
+			w.m.cyclomatic++
+		}
+	case *ast.BranchStmt:
+		// jumps to labels break the linear flow
+		if n.Label != nil {
+			w.m.cognitive++
+		}
+	case *ast.BinaryExpr:
+		if n.Op != token.LAND && n.Op != token.LOR {
+			break
+		}
+		w.m.cyclomatic++
+		if _, ok := w.seen[n]; !ok {
+			// each sequence of like operators increments the cognitive complexity once
+			var prev token.Token
+			for _, op := range w.logicalOps(n, nil) {
+				if op != prev {
+					w.m.cognitive++
+				}
+				prev = op
+			}
+		}
+	case *ast.CallExpr:
+		if id, ok := n.Fun.(*ast.Ident); ok && w.decl != nil && id.Obj != nil && id.Obj.Decl == w.decl {
+			// recursive call
+			w.m.cognitive++
+		}
+	}
+	return w
+}
+
+func (w *metricsWalker) ifStmt(n *ast.IfStmt, elseIf bool) {
+	w.m.cyclomatic++
+	if elseIf {
+		// else-if chains do not increase the nesting
+		w.m.cognitive++
+	} else {
+		w.structural()
+	}
+	w.walk(n.Init)
+	w.walk(n.Cond)
+	w.nested(n.Body)
+	switch e := n.Else.(type) {
+	case *ast.IfStmt:
+		w.m.statements++
+		w.ifStmt(e, true)
+	case *ast.BlockStmt:
+		w.m.cognitive++
+		w.nested(e)
+	}
+}
+
+// structural increments the cognitive complexity for a control-flow statement at the current nesting level.
+func (w *metricsWalker) structural() {
+	w.m.cognitive += 1 + w.nesting
+}
+
+// nested walks the body of a control-flow statement.
+func (w *metricsWalker) nested(body ast.Node) {
+	w.nesting++
+	if w.nesting > w.m.nesting {
+		w.m.nesting = w.nesting
+	}
+	w.walk(body)
+	w.nesting--
+}
+
+func (w *metricsWalker) walk(n ast.Node) {
+	if n == nil {
+		return
+	}
+	ast.Walk(w, n)
+}
+
+// logicalOps lists logical operators of a binary expression in the source order and marks them as seen.
+func (w *metricsWalker) logicalOps(e ast.Expr, ops []token.Token) []token.Token {
+	switch e := e.(type) {
+	case *ast.ParenExpr:
+		return w.logicalOps(e.X, ops)
+	case *ast.BinaryExpr:
+		if e.Op == token.LAND || e.Op == token.LOR {
+			w.seen[e] = struct{}{}
+			ops = w.logicalOps(e.X, ops)
+			ops = append(ops, e.Op)
+			return w.logicalOps(e.Y, ops)
+		}
+	}
+	return ops
+}
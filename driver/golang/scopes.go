@@ -0,0 +1,239 @@
+package golang
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+	"strconv"
+
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+)
+
+const (
+	// typeScope is a type of native nodes that describe a lexical scope.
+	typeScope = "LexicalScope"
+	// typeScopeObject is a type of native nodes that describe an object declared in a scope.
+	typeScopeObject = "ScopeObject"
+
+	// fieldScopes is a field of File set by the scopes analysis.
+	fieldScopes = "Scopes"
+)
+
+// Kinds of scopes.
+const (
+	scopeUniverse = "universe"
+	scopePackage  = "package"
+	scopeFile     = "file"
+	scopeFunction = "function"
+	scopeBlock    = "block"
+)
+
+// universe lists predeclared identifiers of Go, grouped by the kind.
+var universe = []struct {
+	kind  ast.ObjKind
+	names []string
+}{
+	{ast.Typ, []string{
+		"any", "bool", "byte", "comparable", "complex128", "complex64", "error", "float32", "float64",
+		"int", "int16", "int32", "int64", "int8", "rune", "string",
+		"uint", "uint16", "uint32", "uint64", "uint8", "uintptr",
+	}},
+	{ast.Con, []string{"false", "iota", "nil", "true"}},
+	{ast.Fun, []string{
+		"append", "cap", "clear", "close", "complex", "copy", "delete", "imag", "len", "make",
+		"max", "min", "new", "panic", "print", "println", "real", "recover",
+	}},
+}
+
+// universeKind returns the kind of the predeclared identifier, or ast.Bad if the name is not predeclared.
+func universeKind(name string) ast.ObjKind {
+	for _, g := range universe {
+		for _, n := range g.names {
+			if n == name {
+				return g.kind
+			}
+		}
+	}
+	return ast.Bad
+}
+
+// scopeInfo is a lexical scope with the list of identifiers it declares.
+type scopeInfo struct {
+	kind string
+	// node is a node that defines the scope; it is nil for universe and package scopes
+	node     ast.Node
+	objects  []scopeObject
+	children []*scopeInfo
+}
+
+// scopeObject is an object declared in a scope.
+type scopeObject struct {
+	name string
+	kind ast.ObjKind
+	// node is the declaring node: either an identifier or an import spec; it is nil for predeclared objects
+	node ast.Node
+	typ  string
+}
+
+// scopes reconstructs the tree of lexical scopes of the file: universe, package, file, function and block
+// scopes, and returns it as a field of the File node, indexed by its position.
+//
+// The parser only keeps the package scope of the file, thus nested scopes are restored from the declarations
+// that objects refer to. Each object references the native node that declares it by ID (see nodeID).
+// The package scope only lists declarations of the file itself.
+func scopes(f *ast.File, fs *token.FileSet) map[token.Pos]nodes.Object {
+	uni := &scopeInfo{kind: scopeUniverse}
+	for _, g := range universe {
+		for _, name := range g.names {
+			uni.objects = append(uni.objects, scopeObject{name: name, kind: g.kind})
+		}
+	}
+	pkg := &scopeInfo{kind: scopePackage}
+	if f.Scope != nil {
+		for _, obj := range f.Scope.Objects {
+			if id := declIdent(obj); id != nil {
+				pkg.objects = append(pkg.objects, scopeObject{name: obj.Name, kind: obj.Kind, node: id, typ: "Ident"})
+			}
+		}
+		sort.Slice(pkg.objects, func(i, j int) bool {
+			return pkg.objects[i].node.Pos() < pkg.objects[j].node.Pos()
+		})
+	}
+	file := &scopeInfo{kind: scopeFile, node: f}
+	for _, imp := range f.Imports {
+		var name string
+		if imp.Name != nil {
+			name = imp.Name.Name
+		} else if p, err := strconv.Unquote(imp.Path.Value); err == nil {
+			name = importName(p)
+		}
+		if name == "_" || name == "." || name == "" {
+			// blank and dot imports do not declare a package name
+			continue
+		}
+		file.objects = append(file.objects, scopeObject{name: name, kind: ast.Pkg, node: imp, typ: "ImportSpec"})
+	}
+	uni.children = []*scopeInfo{pkg}
+	pkg.children = []*scopeInfo{file}
+	for _, decl := range f.Decls {
+		ast.Walk(&scopeVisitor{file: f, scope: file}, decl)
+	}
+	return map[token.Pos]nodes.Object{
+		f.Pos(): {fieldScopes: uni.toNode(f, fs)},
+	}
+}
+
+// scopeVisitor assigns declarations to the innermost scope.
+type scopeVisitor struct {
+	file  *ast.File
+	scope *scopeInfo
+	// fnc is the innermost function scope that declares labels
+	fnc *scopeInfo
+	// sig is the signature of the function that defines the current scope, and body is either the body
+	// of the function or the body of a switch or select statement; they do not start scopes of their own
+	sig  *ast.FuncType
+	body *ast.BlockStmt
+}
+
+// child opens a new scope defined by the node.
+func (v *scopeVisitor) child(kind string, n ast.Node) *scopeVisitor {
+	s := &scopeInfo{kind: kind, node: n}
+	v.scope.children = append(v.scope.children, s)
+	return &scopeVisitor{file: v.file, scope: s, fnc: v.fnc}
+}
+
+func (v *scopeVisitor) Visit(n ast.Node) ast.Visitor {
+	switch n := n.(type) {
+	case *ast.FuncDecl:
+		c := v.child(scopeFunction, n)
+		c.fnc, c.sig, c.body = c.scope, n.Type, n.Body
+		// the function name is a part of the package scope; the receiver is a part of the function scope
+		if n.Recv != nil {
+			ast.Walk(c, n.Recv)
+		}
+		ast.Walk(c, n.Type)
+		if n.Body != nil {
+			ast.Walk(c, n.Body)
+		}
+		return nil
+	case *ast.FuncLit:
+		c := v.child(scopeFunction, n)
+		c.fnc, c.sig, c.body = c.scope, n.Type, n.Body
+		return c
+	case *ast.FuncType:
+		if n != v.sig {
+			// parameters of function types do not declare anything
+			return nil
+		}
+	case *ast.StructType, *ast.InterfaceType:
+		// fields and methods are not a part of lexical scopes
+		return nil
+	case *ast.BlockStmt:
+		if n == v.body {
+			return v
+		}
+		return v.child(scopeBlock, n)
+	case *ast.SwitchStmt:
+		c := v.child(scopeBlock, n)
+		c.body = n.Body
+		return c
+	case *ast.TypeSwitchStmt:
+		c := v.child(scopeBlock, n)
+		c.body = n.Body
+		return c
+	case *ast.SelectStmt:
+		c := *v
+		c.body = n.Body
+		return &c
+	case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt, *ast.CaseClause, *ast.CommClause:
+		// implicit blocks
+		return v.child(scopeBlock, n)
+	case *ast.Ident:
+		obj := n.Obj
+		if obj == nil || obj.Pos() != n.Pos() || v.file.Scope.Lookup(n.Name) == obj {
+			// not a declaration, or a package-level declaration
+			return nil
+		}
+		s := v.scope
+		if obj.Kind == ast.Lbl && v.fnc != nil {
+			s = v.fnc
+		}
+		s.objects = append(s.objects, scopeObject{name: n.Name, kind: obj.Kind, node: n, typ: "Ident"})
+		return nil
+	}
+	return v
+}
+
+func (s *scopeInfo) toNode(f *ast.File, fs *token.FileSet) nodes.Object {
+	objs := make(nodes.Array, 0, len(s.objects))
+	for _, o := range s.objects {
+		obj := nodes.Object{
+			uast.KeyType: nodes.String(typeScopeObject),
+			"Name":       nodes.String(o.name),
+			"Kind":       nodes.String(o.kind.String()),
+		}
+		if o.node != nil {
+			obj[uast.KeyPos] = nodePositions(o.node, fs).ToObject()
+			obj["Node"] = nodes.String(astNodeID(o.typ, o.node, fs))
+		}
+		objs = append(objs, obj)
+	}
+	children := make(nodes.Array, 0, len(s.children))
+	for _, c := range s.children {
+		children = append(children, c.toNode(f, fs))
+	}
+	obj := nodes.Object{
+		uast.KeyType: nodes.String(typeScope),
+		"Kind":       nodes.String(s.kind),
+		"Objects":    objs,
+		"Scopes":     children,
+	}
+	switch {
+	case s.node != nil:
+		obj[uast.KeyPos] = nodePositions(s.node, fs).ToObject()
+	case s.kind == scopePackage:
+		obj[uast.KeyPos] = nodePositions(f, fs).ToObject()
+	}
+	return obj
+}
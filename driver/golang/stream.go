@@ -0,0 +1,146 @@
+package golang
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/token"
+
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+)
+
+// KeyDecls is a reserved key of the File header emitted by ParseStream. It stores the number
+// of top-level declarations that follow the header.
+const KeyDecls = "@decls"
+
+// ParseStream is like ParseWithOptionsContext, but instead of building the whole native tree it passes
+// parts of the tree to emit as soon as they are converted: first the File node without declarations
+// (the header), and then each top-level declaration in order. The parts are not retained after emit
+// returns, so the native tree is never built as a whole. The memory is not bounded by the largest
+// declaration though: the source code is still parsed at once, and the Go AST of the whole file with
+// its FileSet stays alive until the stream ends. Use Assembler to build the tree from the parts.
+// The parsing stops with the first error returned by emit.
+//
+// The header has the same fields as the File node returned by ParseWithOptions, including Imports,
+// Unresolved, Comments and positions of the whole file, except that Decls is empty and KeyDecls is set.
+// Options.IDs is not supported, since identifiers of duplicate nodes depend on the whole tree.
+//
+// The native protocol of the driver always sends the whole tree in a single response, thus ParseStream
+// is only available to the users of this package.
+func ParseStream(ctx context.Context, code string, opts Options, emit func(n nodes.Node) error) error {
+	if opts.IDs {
+		return errors.New("go driver: node IDs are not supported when streaming")
+	}
+	if max := opts.Limits.MaxSourceBytes; max > 0 && len(code) > max {
+		return &LimitError{Limit: LimitSourceBytes, Max: max}
+	}
+	f, fs, err := ParseStringContext(ctx, code)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return &CanceledError{Err: err}
+	}
+	c := &converter{ctx: ctx, done: ctx.Done(), fs: fs, lim: opts.Limits}
+
+	hdr := *f
+	hdr.Decls = nil
+	n, err := c.convert(&hdr, 1)
+	if err != nil {
+		return err
+	}
+	file := n.(nodes.Object)
+	// the end of the file is the end of the last declaration
+	pos := file[uast.KeyPos].(nodes.Object)
+	pos[uast.KeyEnd] = c.position(f.End())
+	file[KeyDecls] = nodes.Int(len(f.Decls))
+	if opts.Calls {
+		n = attachFields(n, fs, "File", calls(f, fs))
+	}
+	if opts.Scopes {
+		n = attachFields(n, fs, "File", scopes(f, fs))
+	}
+	if opts.Lean {
+		leanNodes(n)
+	}
+	if opts.CompactPositions {
+		compactPositions(n)
+		n.(nodes.Object)[KeyLines] = lineTable(code)
+	}
+	if err := emit(n); err != nil {
+		return err
+	}
+
+	var capt, metr map[token.Pos]nodes.Object
+	if opts.Captures {
+		capt = captures(f, fs)
+	}
+	if opts.Metrics {
+		metr = metrics(f)
+	}
+	for _, d := range f.Decls {
+		// declarations are children of the Decls array of the File
+		n, err := c.convert(d, 2)
+		if err != nil {
+			return err
+		}
+		n = attachFields(n, fs, "FuncLit", capt)
+		n = attachFields(n, fs, "FuncDecl", metr)
+		n = attachFields(n, fs, "FuncLit", metr)
+		if opts.Lean {
+			leanNodes(n)
+		}
+		if opts.CompactPositions {
+			compactPositions(n)
+		}
+		if err := emit(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Assembler builds the native tree from parts emitted by ParseStream.
+type Assembler struct {
+	file  nodes.Object
+	decls nodes.Array
+	// left is the number of declarations that are not added yet
+	left int
+}
+
+// Add adds the next part of the tree. The first part must be the File header.
+func (a *Assembler) Add(n nodes.Node) error {
+	if a.file == nil {
+		file, ok := n.(nodes.Object)
+		if !ok || uast.TypeOf(file) != "File" {
+			return fmt.Errorf("go driver: expected a File header, got %T", n)
+		}
+		cnt, ok := file[KeyDecls].(nodes.Int)
+		if !ok || cnt < 0 {
+			return fmt.Errorf("go driver: invalid number of declarations in the File header: %v", file[KeyDecls])
+		}
+		a.file, a.left = file, int(cnt)
+		a.decls = make(nodes.Array, 0, a.left)
+		return nil
+	}
+	if a.left == 0 {
+		return errors.New("go driver: unexpected declaration after the last one")
+	}
+	a.decls = append(a.decls, n)
+	a.left--
+	return nil
+}
+
+// Node returns the native tree. It returns an error if the header or some declarations were not added.
+// The header passed to Add is modified and used as the root of the tree.
+func (a *Assembler) Node() (nodes.Node, error) {
+	if a.file == nil {
+		return nil, errors.New("go driver: no File header")
+	} else if a.left != 0 {
+		return nil, fmt.Errorf("go driver: %d declarations are missing", a.left)
+	}
+	delete(a.file, KeyDecls)
+	a.file["Decls"] = nilIfEmpty(a.decls)
+	return a.file, nil
+}
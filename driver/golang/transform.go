@@ -0,0 +1,330 @@
+package golang
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"path"
+	"reflect"
+
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+	"github.com/bblfsh/sdk/v3/uast/transformer"
+)
+
+// Transform parses the code, applies the transformer to its native tree and returns the resulting Go code
+// formatted with gofmt (see Format).
+func Transform(code string, t transformer.Transformer) (string, error) {
+	return TransformContext(context.Background(), code, t)
+}
+
+// TransformContext is like Transform, but stops with CanceledError when the context is canceled
+// or its deadline is exceeded.
+func TransformContext(ctx context.Context, code string, t transformer.Transformer) (string, error) {
+	n, err := ParseContext(ctx, code)
+	if err != nil {
+		return "", err
+	}
+	n, err = t.Do(n)
+	if err != nil {
+		return "", err
+	}
+	return Format(n, code)
+}
+
+// Format converts a native tree of the source code src, possibly modified, back to Go code formatted
+// with gofmt.
+//
+// Positions of nodes are only used as hints for the layout of the code: line breaks, blank lines and
+// placement of comments are preserved where the positions allow, while the code itself is printed
+// from the tree. Thus nodes can be inserted, removed or changed without updating positions of other
+// nodes: inserted nodes may have no positions at all, and values of any length can be replaced.
+// The resulting code is parsed to make sure it is valid, thus Parse can be used on it to get
+// a tree with positions of the new code.
+//
+// The code is also type-checked to catch changes that break it, such as dropped imports, duplicate
+// declarations or references to removed names. Only the file itself is checked: other files of the package
+// and imported packages are not loaded, thus errors that src already has, such as references to other files,
+// and references to members of imported packages are not reported.
+func Format(n nodes.Node, src string) (string, error) {
+	f, err := NodeToASTErr(n)
+	if err != nil {
+		return "", err
+	}
+	fs := token.NewFileSet()
+	file := fs.AddFile("input.go", -1, len(src))
+	file.SetLinesForContent([]byte(src))
+	setPositions(reflect.ValueOf(f), n, file)
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fs, f); err != nil {
+		return "", err
+	}
+	// formatting the code again makes sure it is valid and normalizes the layout
+	// of nodes that were printed without positions
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("go driver: invalid code generated from the tree: %v", err)
+	}
+	if err := checkTypes(string(out), src); err != nil {
+		return "", fmt.Errorf("go driver: invalid code generated from the tree: %v", err)
+	}
+	return string(out), nil
+}
+
+// checkTypes type-checks the code and returns the first error that the original code src does not have.
+// Errors are compared by messages, since positions of the code differ.
+func checkTypes(code, src string) error {
+	f, fs, err := ParseString(code)
+	if err != nil {
+		return err
+	}
+	errs := typeErrors(f, fs)
+	if len(errs) == 0 {
+		return nil
+	}
+	known := make(map[string]bool)
+	if f, fs, err := ParseString(src); err == nil {
+		for _, e := range typeErrors(f, fs) {
+			known[e.Msg] = true
+		}
+	}
+	for _, e := range errs {
+		if !known[e.Msg] {
+			return e
+		}
+	}
+	return nil
+}
+
+// typeErrors type-checks a single file and returns all errors, except for references to members
+// of imported packages: the packages are not loaded, thus they are always empty.
+func typeErrors(f *ast.File, fs *token.FileSet) []types.Error {
+	var errs []types.Error
+	conf := types.Config{
+		Importer:    make(emptyImporter),
+		FakeImportC: true,
+		Error: func(err error) {
+			if e, ok := err.(types.Error); ok {
+				errs = append(errs, e)
+			}
+		},
+	}
+	info := &types.Info{Uses: make(map[*ast.Ident]types.Object)}
+	// errors are collected by the callback
+	_, _ = conf.Check(f.Name.Name, fs, []*ast.File{f}, info)
+
+	members := make(map[token.Pos]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				if _, ok := info.Uses[id].(*types.PkgName); ok {
+					members[sel.Sel.Pos()] = true
+				}
+			}
+		}
+		return true
+	})
+	out := errs[:0]
+	for _, e := range errs {
+		if !members[e.Pos] {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// emptyImporter returns empty packages for all imports. The name of a package is guessed from its path.
+type emptyImporter map[string]*types.Package
+
+func (m emptyImporter) Import(importPath string) (*types.Package, error) {
+	if p, ok := m[importPath]; ok {
+		return p, nil
+	}
+	p := types.NewPackage(importPath, path.Base(importPath))
+	p.MarkComplete()
+	m[importPath] = p
+	return p, nil
+}
+
+// Print converts a native tree back to Go code formatted with gofmt. Unlike Format, it ignores positions
+// of nodes, thus the layout of the code is determined by the printer. Only positions from markerPositions
+// are used to tell how the nodes are printed, for example to print variadic calls.
+func Print(n nodes.Node) (string, error) {
+	f, err := NodeToASTErr(n)
+	if err != nil {
+		return "", err
+	}
+	// all markers are set to the same position of a file that has a single line
+	fs := token.NewFileSet()
+	file := fs.AddFile("input.go", -1, 1)
+	setMarkers(reflect.ValueOf(f), n, file.Pos(0))
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fs, f); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// setMarkers sets fields of AST nodes listed in markerPositions to pos, if the native nodes
+// the AST nodes were converted from have valid positions for them.
+func setMarkers(v reflect.Value, n nodes.Node, pos token.Pos) {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	switch n := n.(type) {
+	case nodes.Array:
+		if v.Kind() != reflect.Slice {
+			return
+		}
+		for i := 0; i < len(n) && i < v.Len(); i++ {
+			setMarkers(v.Index(i), n[i], pos)
+		}
+	case nodes.Object:
+		if v.Kind() != reflect.Struct {
+			return
+		}
+		typ := uast.TypeOf(n)
+		positions, _ := n[uast.KeyPos].(nodes.Object)
+		for k, sub := range positions {
+			if !markerPositions[typ+"."+k] {
+				continue
+			}
+			var p uast.Position
+			if o, ok := sub.(nodes.Object); !ok || uast.NodeAs(o, &p) != nil || !p.Valid() {
+				continue
+			}
+			if f := v.FieldByName(k); f.IsValid() && f.Type() == PosType && f.CanSet() {
+				f.Set(reflect.ValueOf(pos))
+			}
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if sub, ok := n[v.Type().Field(i).Name]; ok {
+				setMarkers(v.Field(i), sub, pos)
+			}
+		}
+	}
+}
+
+// markerPositions lists position fields of AST nodes that carry information on their own: the printer
+// checks if they are valid, thus they are never set for inserted nodes.
+var markerPositions = map[string]bool{
+	"CallExpr.Ellipsis": true, // variadic call
+	"TypeSpec.Assign":   true, // alias declaration
+	"GenDecl.Lparen":    true, // parenthesized declaration
+	"GenDecl.Rparen":    true,
+}
+
+// positioner sets token positions of AST nodes from positions of the native tree the nodes were
+// converted from. The native tree and the AST are walked in parallel: fields of native nodes have
+// the same names as fields of AST nodes.
+//
+// Nodes without positions were inserted into the tree. They are placed at the end of the line where
+// the previous node ends, so the printer does not move comments of the neighbouring nodes into them.
+type positioner struct {
+	file *token.File
+	// last is the offset of the last position seen in the order of the source code
+	last int
+}
+
+func setPositions(v reflect.Value, n nodes.Node, file *token.File) {
+	p := &positioner{file: file}
+	p.walk(v, n)
+}
+
+func (p *positioner) walk(v reflect.Value, n nodes.Node) {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	switch n := n.(type) {
+	case nodes.Array:
+		if v.Kind() != reflect.Slice {
+			return
+		}
+		for i := 0; i < len(n) && i < v.Len(); i++ {
+			p.walk(v.Index(i), n[i])
+		}
+	case nodes.Object:
+		if v.Kind() != reflect.Struct {
+			return
+		}
+		pos, _ := n[uast.KeyPos].(nodes.Object)
+		start, ok := p.offset(pos[uast.KeyStart])
+		if ok {
+			p.last = start
+			p.setFields(v, pos)
+		} else {
+			p.setInserted(v, uast.TypeOf(n))
+		}
+		// fields of AST nodes are declared in the order of the source code
+		for i := 0; i < v.NumField(); i++ {
+			if sub, ok := n[v.Type().Field(i).Name]; ok {
+				p.walk(v.Field(i), sub)
+			}
+		}
+		if end, ok := p.offset(pos[uast.KeyEnd]); ok && end > p.last {
+			p.last = end
+		}
+	}
+}
+
+// offset returns the offset of the position, if it is valid and within the file.
+func (p *positioner) offset(n nodes.Node) (int, bool) {
+	o, ok := n.(nodes.Object)
+	if !ok {
+		return 0, false
+	}
+	var pos uast.Position
+	if err := uast.NodeAs(o, &pos); err != nil || !pos.Valid() || int(pos.Offset) > p.file.Size() {
+		return 0, false
+	}
+	return int(pos.Offset), true
+}
+
+// setFields sets token.Pos fields of the AST struct from the positions object of the native node.
+func (p *positioner) setFields(v reflect.Value, pos nodes.Object) {
+	for k, sub := range pos {
+		if k == uast.KeyStart || k == uast.KeyEnd {
+			continue
+		}
+		f := v.FieldByName(k)
+		if !f.IsValid() || f.Type() != PosType || !f.CanSet() {
+			continue
+		}
+		if off, ok := p.offset(sub); ok {
+			f.Set(reflect.ValueOf(p.file.Pos(off)))
+		}
+	}
+}
+
+// setInserted sets all token.Pos fields of the inserted AST struct, except for markerPositions,
+// to the end of the line of the last position.
+func (p *positioner) setInserted(v reflect.Value, typ string) {
+	line := p.file.Line(p.file.Pos(p.last))
+	eol := p.file.Size()
+	if line < p.file.LineCount() {
+		eol = p.file.Offset(p.file.LineStart(line+1)) - 1
+	}
+	pos := p.file.Pos(eol)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Type != PosType || markerPositions[typ+"."+f.Name] {
+			continue
+		}
+		if fv := v.Field(i); fv.CanSet() && fv.Interface().(token.Pos) == token.NoPos {
+			fv.Set(reflect.ValueOf(pos))
+		}
+	}
+}
@@ -6,5 +6,5 @@ import (
 )
 
 func init() {
-	server.DefaultDriver = golang.NewDriver()
+	server.DefaultDriver = golang.NewDriverWithOptions(golang.Options{Limits: golang.DefaultLimits})
 }
@@ -20,6 +20,7 @@ var typeRoles = map[string][]role.Role{
 	"FuncType":       {role.Expression},
 	"Ident":          {role.Expression},
 	"IndexExpr":      {role.Expression},
+	"IndexListExpr":  {role.Expression},
 	"InterfaceType":  {role.Expression},
 	"KeyValueExpr":   {role.Expression},
 	"MapType":        {role.Expression},
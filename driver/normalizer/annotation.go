@@ -3,6 +3,7 @@ package normalizer
 import (
 	"go/token"
 
+	"github.com/bblfsh/go-driver/driver/semantic"
 	"github.com/bblfsh/sdk/v3/uast"
 	"github.com/bblfsh/sdk/v3/uast/nodes"
 	"github.com/bblfsh/sdk/v3/uast/role"
@@ -15,11 +16,13 @@ import (
 // https://godoc.org/github.com/bblfsh/sdk/v3/uast/transformer
 var Native = Transformers([][]Transformer{
 	// The main block of transformation rules.
-	{Mappings(Annotations...)},
+	{Mappings(withIDs(Annotations)...)},
 	{
 		Mappings(
 			AnnotateIfNoRoles("FieldList", role.Incomplete),
 		),
+		// visibility depends on the scope of the declaration, thus it's not a part of the main block
+		visibilityRoles(),
 		// RolesDedup is used to remove duplicate roles assigned by multiple
 		// transformation rules.
 		RolesDedup(),
@@ -185,6 +188,18 @@ var Annotations = []Mapping{
 	annotateType("File", nil, role.File),
 
 	annotateType("CommentGroup", nil, role.Comment, role.List),
+	annotateType(uast.TypeOf(semantic.CommentGroup{}), nil, role.Comment, role.List),
+	annotateType(uast.TypeOf(semantic.DocHeading{}), nil, role.Documentation),
+	annotateType(uast.TypeOf(semantic.DocParagraph{}), nil, role.Documentation),
+	annotateType(uast.TypeOf(semantic.DocCode{}), nil, role.Documentation),
+	annotateType(uast.TypeOf(semantic.DocList{}), nil, role.Documentation, role.List),
+	annotateType(uast.TypeOf(semantic.DocListItem{}), nil, role.Documentation),
+	annotateType(uast.TypeOf(semantic.DocLink{}), nil, role.Documentation),
+	annotateType(uast.TypeOf(semantic.DocSymbolLink{}), nil, role.Documentation),
+	annotateType(uast.TypeOf(semantic.Deprecation{}), nil, role.Documentation),
+	annotateType(uast.TypeOf(semantic.Receiver{}), nil, role.Receiver, role.Type),
+	annotateType(uast.TypeOf(semantic.MethodSets{}), nil, role.Type, role.List),
+	annotateType(uast.TypeOf(semantic.NamedResult{}), nil, role.Return, role.Variable),
 
 	mapAST("Comment", MapObj(Obj{
 		"Text": UncommentCLike("text"),
@@ -398,6 +413,14 @@ var Annotations = []Mapping{
 	annotateType("SelectorExpr", nil, role.Qualified),
 
 	annotateType("CompositeLit", nil, role.Literal),
+	// semantic nodes for composite literals are annotated the same way as native ones
+	annotateType(uast.TypeOf(semantic.CollectionLiteral{}), nil, role.Expression, role.Literal),
+	annotateType(uast.TypeOf(semantic.RecordLiteral{}), nil, role.Expression, role.Literal),
+	annotateType(uast.TypeOf(semantic.UnknownLiteral{}), nil, role.Expression, role.Literal),
+	annotateType(uast.TypeOf(semantic.KeyValue{}), ObjRoles{
+		"Key":   {role.Key},
+		"Value": {role.Value},
+	}, role.Expression),
 	annotateType("ChanType", nil, role.Type, role.Incomplete),
 
 	annotateType("ExprStmt", nil),
@@ -0,0 +1,226 @@
+package normalizer
+
+import (
+	"github.com/bblfsh/go-driver/driver/semantic"
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+	. "github.com/bblfsh/sdk/v3/uast/transformer"
+)
+
+const (
+	// keyCompositeKind is a temporary field of CompositeLit that stores the kind of the literal.
+	// It is set by compositeKinds and consumed by CompositeLit normalizers.
+	keyCompositeKind = "@kind"
+
+	// kindStruct is a kind of struct literals. Other kinds are defined in the semantic package.
+	kindStruct = "struct"
+	// kindUnknown is a kind of literals of types that cannot be resolved.
+	kindUnknown = ""
+
+	// maxTypeDepth limits the number of named types followed when resolving the kind of a literal.
+	maxTypeDepth = 16
+)
+
+var _ Transformer = compositeKinds{}
+
+// compositeKinds determines the kind of each CompositeLit: a slice, an array, a map or a struct.
+//
+// The kind cannot be determined from the node itself if the type of the literal is elided
+// (like in []Point{{1, 2}}) or if it refers to a named type. Thus, this transformation
+// walks the whole file, propagates element types to nested literals and resolves named types
+// declared in the same file. Types declared in functions are resolved in the blocks they are
+// declared in, thus they shadow types with the same names declared in outer blocks.
+//
+// Literals of unknown named types (for example, imported ones) are considered structs
+// if all elements are keyed by identifiers, and are of an unknown kind otherwise.
+type compositeKinds struct{}
+
+func (compositeKinds) Do(root nodes.Node) (nodes.Node, error) {
+	r := &kindResolver{}
+	if file, ok := root.(nodes.Object); ok {
+		decls, _ := file["Decls"].(nodes.Array)
+		r.scopes = append(r.scopes, declaredTypes(decls))
+	}
+	if nn, ok := r.walk(root, nil); ok {
+		return nn, nil
+	}
+	return root, nil
+}
+
+// blockFields maps types of nodes that open a block to their fields that list statements of the block.
+var blockFields = map[string]string{
+	"BlockStmt":  "List",
+	"CaseClause": "Body",
+	"CommClause": "Body",
+}
+
+// declaredTypes returns types declared by a list of declarations or statements, mapped by names.
+func declaredTypes(list nodes.Array) map[string]nodes.Node {
+	types := make(map[string]nodes.Node)
+	for _, d := range list {
+		d, _ := d.(nodes.Object)
+		if uast.TypeOf(d) == "DeclStmt" {
+			d, _ = d["Decl"].(nodes.Object)
+		}
+		if uast.TypeOf(d) != "GenDecl" {
+			continue
+		}
+		specs, _ := d["Specs"].(nodes.Array)
+		for _, spec := range specs {
+			spec, _ := spec.(nodes.Object)
+			if uast.TypeOf(spec) != "TypeSpec" {
+				continue
+			}
+			name, _ := spec["Name"].(nodes.Object)
+			if s, ok := name["Name"].(nodes.String); ok {
+				types[string(s)] = spec["Type"]
+			}
+		}
+	}
+	return types
+}
+
+type kindResolver struct {
+	// scopes map names of types declared in the file to their definitions, starting from
+	// the package scope and ending with the innermost block that is walked
+	scopes []map[string]nodes.Node
+}
+
+// lookup returns the definition of the type visible in the innermost block.
+func (r *kindResolver) lookup(name string) (nodes.Node, bool) {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if def, ok := r.scopes[i][name]; ok {
+			return def, true
+		}
+	}
+	return nil, false
+}
+
+// walk finds all composite literals in the tree and sets their kinds. The exp argument
+// is the type expected for the node, if it's a composite literal with an elided type.
+func (r *kindResolver) walk(n nodes.Node, exp nodes.Node) (nodes.Node, bool) {
+	switch n := n.(type) {
+	case nodes.Array:
+		var out nodes.Array
+		for i, v := range n {
+			if nv, ok := r.walk(v, nil); ok {
+				if out == nil {
+					out = n.CloneList()
+				}
+				out[i] = nv
+			}
+		}
+		if out == nil {
+			return n, false
+		}
+		return out, true
+	case nodes.Object:
+		if uast.TypeOf(n) == "CompositeLit" {
+			return r.composite(n, exp), true
+		}
+		if field, ok := blockFields[uast.TypeOf(n)]; ok {
+			list, _ := n[field].(nodes.Array)
+			r.scopes = append(r.scopes, declaredTypes(list))
+			defer func() { r.scopes = r.scopes[:len(r.scopes)-1] }()
+		}
+		var out nodes.Object
+		for k, v := range n {
+			if nv, ok := r.walk(v, nil); ok {
+				if out == nil {
+					out = n.CloneObject()
+				}
+				out[k] = nv
+			}
+		}
+		if out == nil {
+			return n, false
+		}
+		return out, true
+	}
+	return n, false
+}
+
+func (r *kindResolver) composite(n nodes.Object, exp nodes.Node) nodes.Object {
+	out := n.CloneObject()
+	typ := n["Type"]
+	if typ == nil {
+		typ = exp
+	} else if nt, ok := r.walk(typ, nil); ok {
+		out["Type"] = nt
+	}
+	kind, key, elem := r.kindOf(typ, 0)
+
+	elts, _ := n["Elts"].(nodes.Array)
+	if kind == kindUnknown && keyedByIdent(elts) {
+		kind = kindStruct
+	}
+	if kind == kindStruct {
+		// struct literals cannot elide types of fields
+		key, elem = nil, nil
+	} else if kind != semantic.KindMap {
+		// keys of arrays and slices are indexes
+		key = nil
+	}
+	if len(elts) != 0 {
+		arr := make(nodes.Array, 0, len(elts))
+		for _, e := range elts {
+			if kv, ok := e.(nodes.Object); ok && uast.TypeOf(kv) == "KeyValueExpr" {
+				kv = kv.CloneObject()
+				kv["Key"], _ = r.walk(kv["Key"], key)
+				kv["Value"], _ = r.walk(kv["Value"], elem)
+				e = kv
+			} else {
+				e, _ = r.walk(e, elem)
+			}
+			arr = append(arr, e)
+		}
+		out["Elts"] = arr
+	}
+	out[keyCompositeKind] = nodes.String(kind)
+	return out
+}
+
+// kindOf returns the kind of a composite literal with a given type, as well as the types
+// of keys and elements that nested literals may elide.
+func (r *kindResolver) kindOf(typ nodes.Node, depth int) (kind string, key, elem nodes.Node) {
+	obj, ok := typ.(nodes.Object)
+	if !ok {
+		return kindUnknown, nil, nil
+	}
+	switch uast.TypeOf(obj) {
+	case "ArrayType":
+		if obj["Len"] == nil {
+			return semantic.KindSlice, nil, obj["Elt"]
+		}
+		return semantic.KindArray, nil, obj["Elt"]
+	case "MapType":
+		return semantic.KindMap, obj["Key"], obj["Value"]
+	case "StructType":
+		return kindStruct, nil, nil
+	case "StarExpr", "ParenExpr", "IndexExpr", "IndexListExpr":
+		// &T{} can be elided to {} for elements of []*T;
+		// generic types are resolved by their base type
+		return r.kindOf(obj["X"], depth)
+	case "Ident":
+		name, _ := obj["Name"].(nodes.String)
+		if def, ok := r.lookup(string(name)); ok && depth < maxTypeDepth {
+			return r.kindOf(def, depth+1)
+		}
+	}
+	return kindUnknown, nil, nil
+}
+
+// keyedByIdent checks if all elements of a composite literal are keyed by identifiers.
+func keyedByIdent(elts nodes.Array) bool {
+	for _, e := range elts {
+		kv, ok := e.(nodes.Object)
+		if !ok || uast.TypeOf(kv) != "KeyValueExpr" {
+			return false
+		}
+		key, _ := kv["Key"].(nodes.Object)
+		if uast.TypeOf(key) != "Ident" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,746 @@
+package normalizer
+
+import (
+	"go/ast"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bblfsh/go-driver/driver/semantic"
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+	. "github.com/bblfsh/sdk/v3/uast/transformer"
+)
+
+// keyDocBlocks is a temporary field of CommentGroup that stores parsed blocks of a doc comment.
+// It is set by docComments and consumed by the CommentGroup normalizer.
+const keyDocBlocks = "@doc"
+
+// deprecatedPrefix starts a paragraph of a doc comment that marks the declaration as deprecated.
+const deprecatedPrefix = "Deprecated: "
+
+// keyDeprecated is a field of a declaration that stores its semantic.Deprecation marker.
+const keyDeprecated = "Deprecated"
+
+var (
+	_ Transformer = docComments{}
+	_ Transformer = deprecations{}
+)
+
+// docComments parses doc comments of the file and its declarations according to the Go doc
+// comment syntax: headings, paragraphs, code blocks, lists, links and doc links.
+//
+// Doc links like [Name] or [pkg.Name] are resolved the same way go/doc does, except that
+// only the imports and declarations of the current file are known, not the whole package.
+type docComments struct{}
+
+func (docComments) Do(root nodes.Node) (nodes.Node, error) {
+	p := &docParser{
+		imports: make(map[string]string),
+		syms:    make(map[string]struct{}),
+	}
+	p.collect(root)
+	if nn, ok := p.walk(root); ok {
+		return nn, nil
+	}
+	return root, nil
+}
+
+// docParser parses doc comments of a single file.
+type docParser struct {
+	// imports maps names of imported packages to their import paths
+	imports map[string]string
+	// syms is a set of top-level declarations and methods of the file,
+	// methods are stored as "Recv.Name"
+	syms map[string]struct{}
+}
+
+// collect finds all imports and top-level declarations of the file.
+func (p *docParser) collect(root nodes.Node) {
+	nodes.WalkPreOrder(root, func(n nodes.Node) bool {
+		obj, ok := n.(nodes.Object)
+		if !ok {
+			return true
+		}
+		switch uast.TypeOf(obj) {
+		case "ImportSpec":
+			lit, _ := obj["Path"].(nodes.Object)
+			val, _ := lit["Value"].(nodes.String)
+			ipath, err := strconv.Unquote(string(val))
+			if err != nil {
+				return false
+			}
+			name := path.Base(ipath)
+			if s := identName(obj["Name"]); s != "" {
+				name = s
+			}
+			p.imports[name] = ipath
+			return false
+		case "FuncDecl":
+			name := identName(obj["Name"])
+			if recv, _ := recvType(receiverField(obj["Recv"])["Type"]); recv != "" {
+				name = recv + "." + name
+			}
+			p.syms[name] = struct{}{}
+			return false
+		case "TypeSpec":
+			p.syms[identName(obj["Name"])] = struct{}{}
+			return false
+		case "ValueSpec":
+			names, _ := obj["Names"].(nodes.Array)
+			for _, name := range names {
+				p.syms[identName(name)] = struct{}{}
+			}
+			return false
+		case "FuncLit", "BlockStmt":
+			// local declarations cannot be referenced from doc comments
+			return false
+		}
+		return true
+	})
+}
+
+// identName returns the name of an Ident node, or an empty string if the node is not an identifier.
+func identName(n nodes.Node) string {
+	obj, _ := n.(nodes.Object)
+	if uast.TypeOf(obj) != "Ident" {
+		return ""
+	}
+	name, _ := obj["Name"].(nodes.String)
+	return string(name)
+}
+
+// walk finds all doc comments in the tree and stores their parsed blocks.
+func (p *docParser) walk(n nodes.Node) (nodes.Node, bool) {
+	switch n := n.(type) {
+	case nodes.Array:
+		var out nodes.Array
+		for i, v := range n {
+			if nv, ok := p.walk(v); ok {
+				if out == nil {
+					out = n.CloneList()
+				}
+				out[i] = nv
+			}
+		}
+		if out == nil {
+			return n, false
+		}
+		return out, true
+	case nodes.Object:
+		var out nodes.Object
+		for k, v := range n {
+			nv, ok := p.walk(v)
+			if k == "Doc" {
+				if doc, ok2 := p.doc(nv); ok2 {
+					nv, ok = doc, true
+				}
+			}
+			if ok {
+				if out == nil {
+					out = n.CloneObject()
+				}
+				out[k] = nv
+			}
+		}
+		if out == nil {
+			return n, false
+		}
+		return out, true
+	}
+	return n, false
+}
+
+// doc parses a doc comment, given a native CommentGroup node.
+func (p *docParser) doc(n nodes.Node) (nodes.Object, bool) {
+	obj, ok := n.(nodes.Object)
+	if !ok || uast.TypeOf(obj) != "CommentGroup" {
+		return nil, false
+	}
+	list, _ := obj["List"].(nodes.Array)
+	group := &ast.CommentGroup{List: make([]*ast.Comment, 0, len(list))}
+	for _, c := range list {
+		c, _ := c.(nodes.Object)
+		text, _ := c["Text"].(nodes.String)
+		group.List = append(group.List, &ast.Comment{Text: string(text)})
+	}
+	blocks := p.parse(group.Text())
+	arr := make(nodes.Array, 0, len(blocks))
+	for _, b := range blocks {
+		nd, err := uast.ToNode(b)
+		if err != nil {
+			return nil, false
+		}
+		arr = append(arr, nd)
+	}
+	obj = obj.CloneObject()
+	obj[keyDocBlocks] = arr
+	return obj, true
+}
+
+// parse splits the text of a doc comment into blocks.
+//
+// It follows the rules of go/doc/comment, except for heuristics that only apply to comments
+// which were not formatted with gofmt, like unindented code blocks and lists.
+func (p *docParser) parse(text string) []uast.Any {
+	lines := unindentDoc(strings.Split(text, "\n"))
+	var (
+		blocks []uast.Any
+		links  = make(map[string]string)
+		paras  []*semantic.DocParagraph
+	)
+	for i := 0; i < len(lines); {
+		// skip blank lines
+		if lines[i] == "" {
+			i++
+			continue
+		}
+		start := i
+		if docIndented(lines[i]) {
+			// a code block or a list ends before the next unindented line
+			for i++; i < len(lines) && (lines[i] == "" || docIndented(lines[i])); i++ {
+			}
+			end := i
+			for lines[end-1] == "" {
+				end--
+			}
+			if _, _, ok := listMarker(lines[start]); ok {
+				list, items := docList(lines[start:end])
+				blocks = append(blocks, list)
+				paras = append(paras, items...)
+			} else {
+				body := append(unindentDoc(lines[start:end]), "")
+				blocks = append(blocks, &semantic.DocCode{Text: strings.Join(body, "\n")})
+			}
+			continue
+		}
+		// a paragraph or a heading ends at the next blank or indented line
+		for i++; i < len(lines) && lines[i] != "" && !docIndented(lines[i]); i++ {
+		}
+		switch {
+		case i-start == 1 && isDocHeading(lines[start]):
+			blocks = append(blocks, &semantic.DocHeading{Text: strings.TrimSpace(lines[start][1:])})
+		case i-start == 1 && isOldDocHeading(lines, start):
+			blocks = append(blocks, &semantic.DocHeading{Text: strings.TrimSpace(lines[start])})
+		case isLinkDefs(lines[start:i], links):
+			// link definitions are not a part of the text
+		default:
+			para := &semantic.DocParagraph{Text: strings.Join(lines[start:i], "\n")}
+			para.Deprecated = strings.HasPrefix(para.Text, deprecatedPrefix)
+			blocks = append(blocks, para)
+			paras = append(paras, para)
+		}
+	}
+	// links may be defined after they are used, so paragraphs are interpreted
+	// only when all the definitions are known
+	for _, para := range paras {
+		para.Content = p.linkedText(para.Text, links)
+	}
+	return blocks
+}
+
+// docList builds a list from indented lines. It also returns all paragraphs of list items.
+func docList(lines []string) (*semantic.DocList, []*semantic.DocParagraph) {
+	num, _, _ := listMarker(lines[0])
+	var (
+		list  = &semantic.DocList{}
+		paras []*semantic.DocParagraph
+		item  *semantic.DocListItem
+		text  []string
+	)
+	flush := func() {
+		if item != nil && len(text) != 0 {
+			para := &semantic.DocParagraph{Text: strings.Join(text, "\n")}
+			item.Content = append(item.Content, para)
+			paras = append(paras, para)
+		}
+		text = nil
+	}
+	for _, line := range lines {
+		if n, rest, ok := listMarker(line); ok && (n != "") == (num != "") {
+			flush()
+			list.Items = append(list.Items, semantic.DocListItem{Number: n})
+			item = &list.Items[len(list.Items)-1]
+			line = rest
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			flush()
+			continue
+		}
+		text = append(text, line)
+	}
+	flush()
+	return list, paras
+}
+
+// unindentDoc removes leading and trailing blank lines and the indentation common to all lines.
+// Lines that consist of whitespaces only are replaced with empty lines.
+func unindentDoc(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	prefix := leadingSpace(lines[0])
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) != "" {
+			prefix = commonPrefix(prefix, leadingSpace(line))
+		}
+	}
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		line = strings.TrimPrefix(line, prefix)
+		if strings.TrimSpace(line) == "" {
+			line = ""
+		}
+		out[i] = line
+	}
+	return out
+}
+
+// leadingSpace returns the longest prefix of s consisting of spaces and tabs.
+func leadingSpace(s string) string {
+	i := 0
+	for ; i < len(s) && (s[i] == ' ' || s[i] == '\t'); i++ {
+	}
+	return s[:i]
+}
+
+// docIndented checks if a line of a doc comment starts with a space or a tab.
+func docIndented(line string) bool {
+	return line != "" && (line[0] == ' ' || line[0] == '\t')
+}
+
+// isDocHeading checks if a line is a "# Heading".
+func isDocHeading(line string) bool {
+	return len(line) >= 2 && line[0] == '#' &&
+		(line[1] == ' ' || line[1] == '\t') &&
+		strings.TrimSpace(line) != "#"
+}
+
+// isOldDocHeading checks if lines[i] is an old-style heading: a single capitalized line without
+// punctuation, surrounded by blank lines and followed by a paragraph.
+func isOldDocHeading(lines []string, i int) bool {
+	if i <= 0 || lines[i-1] != "" || i+2 >= len(lines) || lines[i+1] != "" || docIndented(lines[i+2]) {
+		return false
+	}
+	line := strings.TrimSpace(lines[i])
+	if r, _ := utf8.DecodeRuneInString(line); !unicode.IsLetter(r) || !unicode.IsUpper(r) {
+		return false
+	}
+	if r, _ := utf8.DecodeLastRuneInString(line); !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+		return false
+	}
+	if strings.ContainsAny(line, ";:!?+*/=[]{}_^°&§~%#@<\">\\") {
+		return false
+	}
+	// apostrophes are only allowed in possessive "'s"
+	for s := line; ; {
+		j := strings.IndexByte(s, '\'')
+		if j < 0 {
+			break
+		}
+		s = s[j+1:]
+		if s != "s" && !strings.HasPrefix(s, "s ") {
+			return false
+		}
+	}
+	// dots are only allowed inside words
+	for s := line; ; {
+		j := strings.IndexByte(s, '.')
+		if j < 0 {
+			break
+		}
+		s = s[j+1:]
+		if s == "" || s[0] == ' ' {
+			return false
+		}
+	}
+	return true
+}
+
+// isLinkDefs checks if all lines of a paragraph are "[Text]: URL" link definitions,
+// and adds them to the links map if so. The first definition of the text wins.
+func isLinkDefs(lines []string, links map[string]string) bool {
+	type def struct{ text, url string }
+	defs := make([]def, 0, len(lines))
+	for _, line := range lines {
+		if line[0] != '[' {
+			return false
+		}
+		i := strings.Index(line, "]:")
+		if i < 0 || i+3 >= len(line) || (line[i+2] != ' ' && line[i+2] != '\t') {
+			return false
+		}
+		url := strings.TrimSpace(line[i+3:])
+		j := strings.Index(url, "://")
+		if j < 0 || !isURLScheme(url[:j]) {
+			return false
+		}
+		defs = append(defs, def{text: line[1:i], url: url})
+	}
+	for _, d := range defs {
+		if _, ok := links[d.text]; !ok {
+			links[d.text] = d.url
+		}
+	}
+	return true
+}
+
+// listMarker checks if a line starts with a bullet ("-", "*", "+", "•") or a number ("1.", "1)")
+// followed by a space or a tab. It returns the number of the item and the rest of the line.
+func listMarker(line string) (num, rest string, ok bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return "", "", false
+	}
+	if r, n := utf8.DecodeRuneInString(line); r == '•' || r == '*' || r == '+' || r == '-' {
+		rest = line[n:]
+	} else if '0' <= line[0] && line[0] <= '9' {
+		n := 1
+		for n < len(line) && '0' <= line[n] && line[n] <= '9' {
+			n++
+		}
+		if n >= len(line) || (line[n] != '.' && line[n] != ')') {
+			return "", "", false
+		}
+		num, rest = line[:n], line[n+1:]
+	} else {
+		return "", "", false
+	}
+	if !docIndented(rest) || strings.TrimSpace(rest) == "" {
+		return "", "", false
+	}
+	return num, rest, true
+}
+
+// linkedText splits the text of a paragraph into plain strings, links and doc links.
+func (p *docParser) linkedText(text string, links map[string]string) []uast.Any {
+	var (
+		out   []uast.Any
+		wrote = 0
+		start = -1
+	)
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '[':
+			start = i
+		case ']':
+			if start < 0 {
+				break
+			}
+			inner := text[start+1 : i]
+			var link uast.Any
+			// link definitions may span several lines; they are matched with spaces
+			if url, ok := links[strings.NewReplacer("\n", " ", "\t", " ").Replace(inner)]; ok {
+				link = &semantic.DocLink{Text: plainText(inner), URL: url}
+			} else if sym, ok := p.docLink(inner, text[:start], text[i+1:]); ok {
+				link = sym
+			}
+			if link != nil {
+				out = appendPlain(out, text[wrote:start])
+				out = append(out, link)
+				wrote = i + 1
+			}
+			start = -1
+		}
+	}
+	return appendPlain(out, text[wrote:])
+}
+
+// docLink parses the text inside square brackets as a link to a Go package or symbol.
+// Like in go/doc, links must be surrounded by punctuation, spaces or line boundaries.
+func (p *docParser) docLink(text, before, after string) (*semantic.DocSymbolLink, bool) {
+	if r, _ := utf8.DecodeLastRuneInString(before); before != "" && !unicode.IsPunct(r) && !unicode.IsSpace(r) {
+		return nil, false
+	}
+	if r, _ := utf8.DecodeRuneInString(after); after != "" && !unicode.IsPunct(r) && !unicode.IsSpace(r) {
+		return nil, false
+	}
+	link := &semantic.DocSymbolLink{Text: text}
+	pkg, name, ok := splitDocName(strings.TrimPrefix(text, "*"))
+	if ok {
+		pkg, link.Recv, _ = splitDocName(pkg)
+	}
+	link.Name = name
+	if pkg == "" {
+		sym := name
+		if link.Recv != "" {
+			sym = link.Recv + "." + name
+		}
+		_, ok = p.syms[sym]
+		return link, ok
+	}
+	link.ImportPath, ok = p.lookupPkg(pkg)
+	return link, ok
+}
+
+// lookupPkg resolves a package name or an import path used in a doc link.
+func (p *docParser) lookupPkg(pkg string) (string, bool) {
+	if strings.Contains(pkg, "/") {
+		return pkg, validImportPath(pkg)
+	}
+	if ipath, ok := p.imports[pkg]; ok {
+		return ipath, true
+	}
+	_, ok := stdPackages[pkg]
+	return pkg, ok
+}
+
+// stdPackages is a set of standard packages with single-element import paths.
+var stdPackages = map[string]struct{}{
+	"bufio": {}, "bytes": {}, "cmp": {}, "context": {}, "crypto": {}, "embed": {},
+	"errors": {}, "expvar": {}, "flag": {}, "fmt": {}, "hash": {}, "html": {},
+	"image": {}, "io": {}, "iter": {}, "log": {}, "maps": {}, "math": {},
+	"mime": {}, "net": {}, "os": {}, "path": {}, "plugin": {}, "reflect": {},
+	"regexp": {}, "runtime": {}, "slices": {}, "sort": {}, "strconv": {}, "strings": {},
+	"structs": {}, "sync": {}, "syscall": {}, "testing": {}, "time": {}, "unicode": {},
+	"unique": {}, "unsafe": {}, "weak": {},
+}
+
+// splitDocName splits "before.Name" into before and Name, if Name is a capitalized identifier.
+// Otherwise it returns the text as is.
+func splitDocName(text string) (before, name string, ok bool) {
+	i := strings.LastIndex(text, ".")
+	name = text[i+1:]
+	if !isExportedName(name) {
+		return text, "", false
+	}
+	if i >= 0 {
+		before = text[:i]
+	}
+	return before, name, true
+}
+
+// isExportedName checks if s is a capitalized Go identifier.
+func isExportedName(s string) bool {
+	for i, r := range s {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsUpper(r)
+}
+
+// validImportPath checks if the path consists of non-empty elements with allowed characters.
+func validImportPath(ipath string) bool {
+	if ipath == "" || ipath[0] == '-' {
+		return false
+	}
+	for _, elem := range strings.Split(ipath, "/") {
+		if elem == "" || elem[0] == '.' || elem[len(elem)-1] == '.' {
+			return false
+		}
+		for i := 0; i < len(elem); i++ {
+			c := elem[i]
+			if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("-._~+", c) >= 0) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// appendPlain splits plain text into strings and automatic URL links, and appends them to out.
+func appendPlain(out []uast.Any, s string) []uast.Any {
+	wrote := 0
+	for i := 0; i < len(s); {
+		url, ok := autoURL(s[i:])
+		if !ok {
+			i++
+			continue
+		}
+		if wrote < i {
+			out = append(out, plainText(s[wrote:i]))
+		}
+		out = append(out, &semantic.DocLink{Text: url, URL: url, Auto: true})
+		i += len(url)
+		wrote = i
+	}
+	if wrote < len(s) {
+		out = append(out, plainText(s[wrote:]))
+	}
+	return out
+}
+
+// plainText replaces pairs of backquotes and single quotes with typographic quotes, as go/doc does.
+func plainText(s string) string {
+	if !strings.Contains(s, "``") && !strings.Contains(s, "''") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		switch t := s[i:]; {
+		case strings.HasPrefix(t, "```"):
+			// Markdown code fences are left as is
+			j := i + 3
+			for j < len(s) && s[j] == '`' {
+				j++
+			}
+			b.WriteString(s[i:j])
+			i = j
+		case strings.HasPrefix(t, "``"):
+			b.WriteRune('“')
+			i += 2
+		case strings.HasPrefix(t, "''"):
+			b.WriteRune('”')
+			i += 2
+		default:
+			b.WriteByte(s[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// autoURL checks if s starts with an URL and returns it.
+// Trailing punctuation and unbalanced brackets are not considered a part of the URL.
+func autoURL(s string) (string, bool) {
+	i := strings.Index(s, "://")
+	if i < 3 || i > 6 || !isURLScheme(s[:i]) {
+		return "", false
+	}
+	i += 3
+	if i >= len(s) || !isURLHost(s[i]) || isURLPunct(s[i]) {
+		return "", false
+	}
+	end := i + 1
+	for i++; i < len(s) && isURLHost(s[i]); i++ {
+		if !isURLPunct(s[i]) {
+			end = i + 1
+		}
+	}
+	var stack []byte
+	for i = end; i < len(s); i++ {
+		c := s[i]
+		if isURLPunct(c) {
+			continue
+		}
+		if !isURLPath(c) {
+			break
+		}
+		if j := strings.IndexByte("({[", c); j >= 0 {
+			stack = append(stack, ")}]"[j])
+		} else if strings.IndexByte(")}]", c) >= 0 {
+			if len(stack) == 0 || stack[len(stack)-1] != c {
+				break
+			}
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			end = i + 1
+		}
+	}
+	return s[:end], true
+}
+
+func isURLScheme(s string) bool {
+	switch s {
+	case "file", "ftp", "gopher", "http", "https", "mailto", "nntp":
+		return true
+	}
+	return false
+}
+
+func isAlnum(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+func isURLHost(c byte) bool {
+	return isAlnum(c) || strings.IndexByte("_@-.[]:", c) >= 0
+}
+
+// isURLPunct checks if c is a punctuation that can appear inside an URL, but not at the end.
+func isURLPunct(c byte) bool {
+	return strings.IndexByte(".,:;?!", c) >= 0
+}
+
+func isURLPath(c byte) bool {
+	return isAlnum(c) || strings.IndexByte("$'()*+&#=@~_/-[]{}%", c) >= 0
+}
+
+// deprecations marks declarations that have a "Deprecated: " paragraph in their doc comments:
+// functions, methods, types, fields, constants and variables. It must run on a normalized tree,
+// after doc comments are parsed and attached to declarations.
+type deprecations struct{}
+
+func (deprecations) Do(root nodes.Node) (nodes.Node, error) {
+	if nn, ok := markDeprecated(root); ok {
+		return nn, nil
+	}
+	return root, nil
+}
+
+// markDeprecated sets the deprecation marker on all deprecated declarations in the tree.
+func markDeprecated(n nodes.Node) (nodes.Node, bool) {
+	switch n := n.(type) {
+	case nodes.Array:
+		var out nodes.Array
+		for i, v := range n {
+			if nv, ok := markDeprecated(v); ok {
+				if out == nil {
+					out = n.CloneList()
+				}
+				out[i] = nv
+			}
+		}
+		if out == nil {
+			return n, false
+		}
+		return out, true
+	case nodes.Object:
+		var out nodes.Object
+		for k, v := range n {
+			if nv, ok := markDeprecated(v); ok {
+				if out == nil {
+					out = n.CloneObject()
+				}
+				out[k] = nv
+			}
+		}
+		if msg, ok := deprecationMessage(n["Doc"]); ok {
+			nd, err := uast.ToNode(semantic.Deprecation{Message: msg})
+			if err == nil {
+				if out == nil {
+					out = n.CloneObject()
+				}
+				out[keyDeprecated] = nd
+			}
+		}
+		if out == nil {
+			return n, false
+		}
+		return out, true
+	}
+	return n, false
+}
+
+// deprecationMessage returns the message of the first "Deprecated: " paragraph of a doc comment,
+// given a semantic.CommentGroup node.
+func deprecationMessage(doc nodes.Node) (string, bool) {
+	obj, ok := doc.(nodes.Object)
+	if !ok || uast.TypeOf(obj) != uast.TypeOf(semantic.CommentGroup{}) {
+		return "", false
+	}
+	blocks, _ := obj["Blocks"].(nodes.Array)
+	for _, b := range blocks {
+		b, _ := b.(nodes.Object)
+		if uast.TypeOf(b) != uast.TypeOf(semantic.DocParagraph{}) {
+			continue
+		}
+		if dep, _ := b["Deprecated"].(nodes.Bool); !dep {
+			continue
+		}
+		text, _ := b["Text"].(nodes.String)
+		return strings.TrimSpace(strings.TrimPrefix(string(text), deprecatedPrefix)), true
+	}
+	return "", false
+}
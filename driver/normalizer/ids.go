@@ -0,0 +1,62 @@
+package normalizer
+
+import (
+	"github.com/bblfsh/go-driver/driver/golang"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+	. "github.com/bblfsh/sdk/v3/uast/transformer"
+)
+
+// withIDs makes mappings preserve node IDs assigned by the driver (see golang.KeyID).
+// Object mappings are strict about the set of fields, thus the ID is moved to the resulting node explicitly.
+func withIDs(maps []Mapping) []Mapping {
+	out := make([]Mapping, 0, len(maps))
+	for _, m := range maps {
+		if om, ok := m.(ObjMapping); ok {
+			// partial mappings keep unknown fields as-is
+			if so, _ := om.ObjMapping(); isStrict(so) {
+				m = withOptional(om, golang.KeyID, golang.KeyID)
+			}
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func isStrict(op ObjectOp) bool {
+	_, full := op.Fields()
+	return full
+}
+
+// dropID extends an object operation for a nested node that has no counterpart in the resulting tree.
+// The ID of such node is dropped, if any.
+func dropID(o ObjectOp) ObjectOp {
+	return JoinObj(o, Fields{{Name: golang.KeyID, Drop: true, Op: Any()}})
+}
+
+// withoutIDs returns a copy of the tree without node IDs. It is used for nodes duplicated by
+// the normalizer, since an ID must refer to a single node of the tree.
+func withoutIDs(n nodes.Node) nodes.Node {
+	switch n := n.(type) {
+	case nodes.Object:
+		if n == nil {
+			return n
+		}
+		out := make(nodes.Object, len(n))
+		for k, v := range n {
+			if k != golang.KeyID {
+				out[k] = withoutIDs(v)
+			}
+		}
+		return out
+	case nodes.Array:
+		if n == nil {
+			return n
+		}
+		out := make(nodes.Array, len(n))
+		for i, v := range n {
+			out[i] = withoutIDs(v)
+		}
+		return out
+	}
+	return n
+}
@@ -0,0 +1,163 @@
+package normalizer
+
+import (
+	"github.com/bblfsh/go-driver/driver/semantic"
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+	. "github.com/bblfsh/sdk/v3/uast/transformer"
+)
+
+const (
+	// keyReceiver is a temporary field of FuncDecl that stores a semantic.Receiver node.
+	// It is set by methodSets and is moved to the Receiver field by the FuncDecl normalizer.
+	keyReceiver = "@recv"
+
+	// keyMethodSets is a field of TypeSpec that stores a semantic.MethodSets node.
+	keyMethodSets = "MethodSets"
+)
+
+var _ Transformer = methodSets{}
+
+// methodSets describes receivers of all methods declared in the file and attaches
+// method sets to declarations of their receiver types.
+//
+// Only methods declared in the same file are known, thus method sets of a type declared
+// in one file and extended in another one are incomplete.
+type methodSets struct{}
+
+func (methodSets) Do(root nodes.Node) (nodes.Node, error) {
+	file, ok := root.(nodes.Object)
+	if !ok || uast.TypeOf(file) != "File" {
+		return root, nil
+	}
+	decls, _ := file["Decls"].(nodes.Array)
+	if len(decls) == 0 {
+		return root, nil
+	}
+	decls = decls.CloneList()
+
+	// describe receivers and collect methods of each type
+	type methodSet struct {
+		value, pointer nodes.Array
+	}
+	methods := make(map[string]*methodSet)
+	for i, d := range decls {
+		fnc, ok := d.(nodes.Object)
+		if !ok || uast.TypeOf(fnc) != "FuncDecl" {
+			continue
+		}
+		recv, ptr, ok := receiverOf(fnc["Recv"])
+		if !ok {
+			continue
+		}
+		fnc = fnc.CloneObject()
+		fnc[keyReceiver] = recv
+		decls[i] = fnc
+
+		typ := string(recv["Type"].(nodes.String))
+		ms := methods[typ]
+		if ms == nil {
+			ms = &methodSet{value: nodes.Array{}, pointer: nodes.Array{}}
+			methods[typ] = ms
+		}
+		// the method set of *T includes methods declared with receivers of both T and *T
+		name := nodes.String(identName(fnc["Name"]))
+		if !ptr {
+			ms.value = append(ms.value, name)
+		}
+		ms.pointer = append(ms.pointer, name)
+	}
+
+	// attach method sets to type declarations
+	for i, d := range decls {
+		decl, ok := d.(nodes.Object)
+		if !ok || uast.TypeOf(decl) != "GenDecl" {
+			continue
+		}
+		specs, _ := decl["Specs"].(nodes.Array)
+		var out nodes.Array
+		for j, s := range specs {
+			spec, ok := s.(nodes.Object)
+			if !ok || uast.TypeOf(spec) != "TypeSpec" {
+				continue
+			}
+			ms := methods[identName(spec["Name"])]
+			if ms == nil {
+				continue
+			}
+			nd := nodes.Object{
+				uast.KeyType: nodes.String(uast.TypeOf(semantic.MethodSets{})),
+				"Value":      ms.value,
+				"Pointer":    ms.pointer,
+			}
+			if out == nil {
+				out = specs.CloneList()
+			}
+			spec = spec.CloneObject()
+			spec[keyMethodSets] = nd
+			out[j] = spec
+		}
+		if out != nil {
+			decl = decl.CloneObject()
+			decl["Specs"] = out
+			decls[i] = decl
+		}
+	}
+	file = file.CloneObject()
+	file["Decls"] = decls
+	return file, nil
+}
+
+// receiverOf describes the receiver of a method as a semantic.Receiver node, given the Recv field
+// list of FuncDecl. It also reports if the receiver is a pointer.
+func receiverOf(n nodes.Node) (nodes.Object, bool, bool) {
+	field := receiverField(n)
+	typ, ptr := recvType(field["Type"])
+	if typ == "" {
+		return nil, false, false
+	}
+	recv := semantic.Receiver{Type: typ, Pointer: ptr, Blank: true}
+	if names, _ := field["Names"].(nodes.Array); len(names) == 1 {
+		recv.Name = identName(names[0])
+		recv.Blank = recv.Name == "_"
+	}
+	nd, err := uast.ToNode(recv)
+	if err != nil {
+		return nil, false, false
+	}
+	obj := nd.(nodes.Object)
+	if ps, ok := field[uast.KeyPos]; ok {
+		obj[uast.KeyPos] = ps
+	}
+	return obj, ptr, true
+}
+
+// receiverField returns the receiver field, given the Recv field list of FuncDecl.
+func receiverField(n nodes.Node) nodes.Object {
+	list, _ := n.(nodes.Object)
+	fields, _ := list["List"].(nodes.Array)
+	if len(fields) != 1 {
+		return nil
+	}
+	field, _ := fields[0].(nodes.Object)
+	return field
+}
+
+// recvType returns the base type name of a method receiver and reports if it's a pointer.
+// Type parameters of generic receivers are ignored.
+func recvType(typ nodes.Node) (name string, ptr bool) {
+	for {
+		obj, _ := typ.(nodes.Object)
+		switch uast.TypeOf(obj) {
+		case "StarExpr":
+			ptr = true
+			typ = obj["X"]
+		case "ParenExpr", "IndexExpr", "IndexListExpr":
+			typ = obj["X"]
+		case "Ident":
+			return identName(obj), ptr
+		default:
+			return "", false
+		}
+	}
+}
@@ -1,17 +1,26 @@
 package normalizer
 
 import (
+	"fmt"
+	"go/ast"
 	"go/token"
+	"strconv"
 	"strings"
 	"unicode"
 
+	"github.com/bblfsh/go-driver/driver/golang"
+	"github.com/bblfsh/go-driver/driver/semantic"
 	"github.com/bblfsh/sdk/v3/uast"
 	"github.com/bblfsh/sdk/v3/uast/nodes"
 	. "github.com/bblfsh/sdk/v3/uast/transformer"
 )
 
+// keyMetrics is a field of FuncDecl and FuncLit set by the optional metrics analysis of the driver.
+// It is moved from FuncDecl to the FunctionGroup node.
+const keyMetrics = "Metrics"
+
 var Preprocess = Transformers([][]Transformer{
-	{Mappings(
+	{Mappings(withIDs([]Mapping{
 		MapPart("_", MapObj( // remove unresolved
 			Obj{
 				uast.KeyType: String("File"),
@@ -21,12 +30,16 @@ var Preprocess = Transformers([][]Transformer{
 				uast.KeyType: String("File"),
 			},
 		)),
-	)},
+	})...)},
 }...)
 
 var Normalize = Transformers([][]Transformer{
+	// Composite literals and doc links need the whole file to be resolved.
+	{compositeKinds{}, docComments{}, methodSets{}, namedResults{}},
 	// The main block of normalization rules.
-	{Mappings(Normalizers...)},
+	{Mappings(withIDs(Normalizers)...)},
+	// Doc comments are moved to declarations by normalizers.
+	{deprecations{}},
 }...)
 
 var Normalizers = []Mapping{
@@ -44,7 +57,7 @@ var Normalizers = []Mapping{
 		MapObj(
 			Obj{
 				"Kind":  isGoTok(token.STRING),
-				"Value": Quote(Var("val")), // TODO: store quote type
+				"Value": quoteString{vr: "val"}, // TODO: store quote type
 			},
 			Obj{
 				"Value":  Var("val"),
@@ -74,6 +87,44 @@ var Normalizers = []Mapping{
 		),
 	),
 
+	MapSemantic("CommentGroup", semantic.CommentGroup{},
+		MapObj(
+			Fields{
+				{Name: "List", Op: commentGroupText{list: "comments", text: "text"}},
+				// set for doc comments only
+				{Name: keyDocBlocks, Optional: "doc_exists", Op: Var("blocks")},
+			},
+			Fields{
+				{Name: "Comments", Op: Var("comments")},
+				{Name: "Text", Op: Var("text")},
+				{Name: "Blocks", Optional: "doc_exists", Op: Var("blocks")},
+			},
+		),
+	),
+
+	// doc comment of a declaration without parentheses documents its only spec
+	MapPart("decl", MapObj(
+		Obj{
+			uast.KeyType: String("GenDecl"),
+			uast.KeyPos: Check(
+				Has{"Lparen": Is(uast.Position{})},
+				Var("pos"),
+			),
+			"Doc": Check(NotNil(), Var("doc")),
+			"Specs": One(Part("spec", Fields{
+				{Name: "Doc", Drop: true, Op: Is(nil)},
+			})),
+		},
+		Obj{
+			uast.KeyType: String("GenDecl"),
+			uast.KeyPos:  Var("pos"),
+			"Doc":        Is(nil),
+			"Specs": One(Part("spec", Obj{
+				"Doc": Var("doc"),
+			})),
+		},
+	)),
+
 	MapSemanticPos("BlockStmt", uast.Block{},
 		map[string]string{
 			"Lbrace": "start",
@@ -89,6 +140,105 @@ var Normalizers = []Mapping{
 		),
 	),
 
+	MapSemanticPos("KeyValueExpr", semantic.KeyValue{},
+		map[string]string{
+			"Colon": "colon",
+		},
+		MapObj(
+			Obj{
+				"Key":   Var("key"),
+				"Value": Var("val"),
+			},
+			Obj{
+				"Key":   Var("key"),
+				"Value": Var("val"),
+			},
+		),
+	),
+
+	MapSemanticPos("CompositeLit", semantic.RecordLiteral{},
+		map[string]string{
+			"Lbrace": "lbrace",
+			"Rbrace": "rbrace",
+		},
+		MapObj(
+			Obj{
+				keyCompositeKind: String(kindStruct),
+				"Incomplete":     Bool(false),
+				"Type":           Var("type"),
+				"Elts": Cases("elts_case",
+					Is(nil),
+					Check(NotNil(), Var("elts")),
+				),
+			},
+			Obj{
+				"Type": Var("type"),
+				"Fields": Cases("elts_case",
+					Arr(),
+					Check(NotNil(), Var("elts")),
+				),
+			},
+		),
+	),
+
+	MapSemanticPos("CompositeLit", semantic.CollectionLiteral{},
+		map[string]string{
+			"Lbrace": "lbrace",
+			"Rbrace": "rbrace",
+		},
+		MapObj(
+			Obj{
+				keyCompositeKind: Check(
+					In(
+						nodes.String(semantic.KindSlice),
+						nodes.String(semantic.KindArray),
+						nodes.String(semantic.KindMap),
+					),
+					Var("kind"),
+				),
+				"Incomplete": Bool(false),
+				"Type":       Var("type"),
+				"Elts": Cases("elts_case",
+					Is(nil),
+					Check(NotNil(), Var("elts")),
+				),
+			},
+			Obj{
+				"Kind": Var("kind"),
+				"Type": Var("type"),
+				"Elements": Cases("elts_case",
+					Arr(),
+					Check(NotNil(), Var("elts")),
+				),
+			},
+		),
+	),
+
+	MapSemanticPos("CompositeLit", semantic.UnknownLiteral{},
+		map[string]string{
+			"Lbrace": "lbrace",
+			"Rbrace": "rbrace",
+		},
+		MapObj(
+			Obj{
+				keyCompositeKind: String(kindUnknown),
+				"Incomplete":     Bool(false),
+				"Type":           Var("type"),
+				"Elts": Cases("elts_case",
+					Is(nil),
+					Check(NotNil(), Var("elts")),
+				),
+			},
+			Obj{
+				"Type": Var("type"),
+				"Elements": Cases("elts_case",
+					Arr(),
+					Check(NotNil(), Var("elts")),
+				),
+			},
+		),
+	),
+
 	MapPart("flist", ObjMap{
 		uast.KeyType: String("FieldList"),
 		"List": Map(
@@ -97,29 +247,32 @@ var Normalizers = []Mapping{
 		),
 	}),
 
+	MapPart("field", ObjMap{
+		uast.KeyType: String("Field"),
+		"Tag":        Map(structTag{Var("tag")}, Var("tag")),
+	}),
+
 	// all-in-one
-	MapSemanticPos("ImportSpec", uast.Import{},
+	withComments(MapSemanticPos("ImportSpec", uast.Import{},
 		map[string]string{
 			"EndPos": "endp",
 		},
 		MapObj(
 			Obj{
-				"Comment": Is(nil),
-				"Doc":     Is(nil),
-				"Path":    pathSplit{Var("path")},
+				"Path": pathSplit{Var("path")},
 				"Name": Cases("case",
 					// case 1: no alias for the import
 					Is(nil),
 					// case 2: side-effect import
-					UASTType(uast.Identifier{}, Obj{
+					dropID(UASTType(uast.Identifier{}, Obj{
 						uast.KeyPos: AnyNode(nil),
 						"Name":      String("_"),
-					}),
+					})),
 					// case 3: import to the package scope
-					UASTType(uast.Identifier{}, Obj{
+					dropID(UASTType(uast.Identifier{}, Obj{
 						uast.KeyPos: AnyNode(nil),
 						"Name":      String("."),
-					}),
+					})),
 				),
 			},
 			// ->
@@ -150,20 +303,18 @@ var Normalizers = []Mapping{
 				},
 			),
 		),
-	),
+	), "Doc", "Comment"),
 
 	// alias
-	MapSemanticPos("ImportSpec", uast.Import{},
+	withComments(MapSemanticPos("ImportSpec", uast.Import{},
 		map[string]string{
 			"EndPos": "endp",
 		},
 
 		MapObj(
 			Obj{
-				"Comment": Is(nil),
-				"Doc":     Is(nil),
-				"Name":    Var("alias"),
-				"Path":    pathSplit{Var("path")},
+				"Name": Var("alias"),
+				"Path": pathSplit{Var("path")},
 			},
 			// ->
 			Obj{
@@ -178,12 +329,12 @@ var Normalizers = []Mapping{
 				"Names": Arr(),
 			},
 		),
-	),
+	), "Doc", "Comment"),
 
 	MapPart("func", ObjMap{
 		uast.KeyType: String("FuncType"),
 		"Params": Map(
-			Obj{
+			dropID(Obj{
 				uast.KeyType: String("FieldList"),
 				// FIXME: store positions?
 				// "Opening" same as start
@@ -193,7 +344,7 @@ var Normalizers = []Mapping{
 					Is(nil),
 					Check(NotNil(), Var("args")),
 				),
-			},
+			}),
 			Cases("list",
 				Arr(),
 				Check(NotNil(), Var("args")),
@@ -202,14 +353,14 @@ var Normalizers = []Mapping{
 		"Results": Map(
 			Cases("res",
 				Is(nil),
-				Obj{
+				dropID(Obj{
 					uast.KeyType: String("FieldList"),
 					// FIXME: store positions?
 					// "Opening" same as start
 					// "Closing" same as end
 					uast.KeyPos: AnyNode(nil),
 					"List":      Var("out"),
-				},
+				}),
 			),
 			Cases("res",
 				Is(nil),
@@ -222,7 +373,7 @@ var Normalizers = []Mapping{
 		"Params":     MapEach("args", fieldMap),
 		"Results":    MapEach("res", fieldMap),
 	}),
-	MapSemanticPos("FuncType", uast.FunctionType{},
+	withOptional(MapSemanticPos("FuncType", uast.FunctionType{},
 		map[string]string{
 			"Func": "pos_func",
 		},
@@ -236,20 +387,20 @@ var Normalizers = []Mapping{
 				"Returns":   Var("out"),
 			},
 		),
-	),
+	), keyNamedResults, "NamedResults"),
 	MapPart("func", ObjMap{
 		uast.KeyType: String("FuncDecl"),
 		"Recv": Map(
 			Cases("recv",
 				Is(nil),
-				Obj{
+				dropID(Obj{
 					uast.KeyType: String("FieldList"),
 					// FIXME: store positions?
 					// "Opening" same as start
 					// "Closing" same as end
 					uast.KeyPos: AnyNode(nil),
 					"List":      Var("out"),
-				},
+				}),
 			),
 			Cases("recv",
 				Is(nil),
@@ -261,14 +412,13 @@ var Normalizers = []Mapping{
 		uast.KeyType: String("FuncDecl"),
 		"Recv":       MapEach("recv", fieldMap),
 	}),
-	MapSemantic("FuncDecl", uast.FunctionGroup{},
+	withOptional(withOptional(withComments(MapSemantic("FuncDecl", uast.FunctionGroup{},
 		MapObj(
 			CasesObj("recv_case",
 				// common
 				Obj{
 					"Name": Var("name"),
 					"Body": Var("body"),
-					"Doc":  Var("doc"),
 				},
 				Objs{
 					// case 1: no receiver
@@ -292,7 +442,6 @@ var Normalizers = []Mapping{
 			// ->
 			Obj{
 				"Nodes": Arr(
-					Var("doc"), // FIXME: do not insert if it's nil
 					UASTType(uast.Alias{}, Obj{
 						// FIXME: position
 						"Name": Var("name"),
@@ -304,7 +453,11 @@ var Normalizers = []Mapping{
 							Objs{
 								// case 1: no receiver - store type as-is
 								{
-									"Type": Var("type"),
+									// the check is only needed to distinguish cases in the reverse direction
+									"Type": Check(
+										Not(Has{"Arguments": PrependOne(Check(Has{"Receiver": Bool(true)}, Any()), Any())}),
+										Var("type"),
+									),
 								},
 								// case 2: receiver - need to inject as a first argument with a flag
 								{
@@ -323,15 +476,14 @@ var Normalizers = []Mapping{
 				),
 			},
 		),
-	),
+	), "Doc"), keyReceiver, "Receiver"), keyMetrics, "Metrics"),
 }
 
-var fieldMap = MapSemantic("Field", uast.Argument{},
+// fieldMap is applied to fields of function types by other mappings, thus it preserves node IDs on its own.
+var fieldMap = withOptional(withComments(MapSemantic("Field", uast.Argument{},
 	MapObj(
 		Obj{
-			"Comment": Is(nil), // FIXME: is it possible to attach it?
-			"Doc":     Is(nil),
-			"Tag":     Is(nil),
+			"Tag": Is(nil),
 			"Names": Cases("names",
 				Is(nil),
 				Arr( // another transform makes sure that there is only one name
@@ -340,13 +492,13 @@ var fieldMap = MapSemantic("Field", uast.Argument{},
 			),
 			"Type": Cases("variadic",
 				// case 1: variadic
-				Obj{
+				dropID(Obj{
 					uast.KeyType: String("Ellipsis"),
 					// FIXME: store positions?
 					// "Ellipsis" same as start
 					uast.KeyPos: AnyNode(nil),
 					"Elt":       Var("type"),
-				},
+				}),
 				// case 2: normal arg
 				Check(
 					Not(Has{uast.KeyType: String("Ellipsis")}),
@@ -372,7 +524,32 @@ var fieldMap = MapSemantic("Field", uast.Argument{},
 			},
 		),
 	),
-)
+), "Doc", "Comment"), golang.KeyID, golang.KeyID)
+
+// withComments maps comment groups of a declaration (like Doc or Comment) to optional fields
+// with the same names on the semantic node. The field is omitted if there are no comments.
+func withComments(m ObjMapping, names ...string) ObjMapping {
+	src := make(Fields, 0, len(names))
+	dst := make(Fields, 0, len(names))
+	for _, name := range names {
+		vr := "comments_" + strings.ToLower(name)
+		src = append(src, Field{Name: name, Op: Opt(vr+"_exists", Var(vr))})
+		dst = append(dst, Field{Name: name, Op: Var(vr), Optional: vr + "_exists"})
+	}
+	so, do := m.ObjMapping()
+	return MapObj(JoinObj(so, src), JoinObj(do, dst))
+}
+
+// withOptional maps an optional temporary field set by one of the transformers (like keyReceiver)
+// to an optional field of the semantic node. The field is omitted if it was not set.
+func withOptional(m ObjMapping, from, to string) ObjMapping {
+	vr := "optional_" + strings.ToLower(to)
+	so, do := m.ObjMapping()
+	return MapObj(
+		JoinObj(so, Fields{{Name: from, Optional: vr + "_exists", Op: Var(vr)}}),
+		JoinObj(do, Fields{{Name: to, Optional: vr + "_exists", Op: Var(vr)}}),
+	)
+}
 
 type commentNorm struct {
 	text, block     string
@@ -414,7 +591,21 @@ func (op commentNorm) Check(st *State, n nodes.Node) (bool, error) {
 	suff = text[i+1:]
 	text = text[:i+1]
 
-	// TODO: set tab
+	// find tab: an indentation common to all lines of a multi-line block comment except the first one
+	if block {
+		if lines := strings.Split(text, "\n"); len(lines) > 1 {
+			tab = commentIndent(lines[1])
+			for _, line := range lines[2:] {
+				tab = commonPrefix(tab, commentIndent(line))
+			}
+			if tab != "" {
+				for i := 1; i < len(lines); i++ {
+					lines[i] = lines[i][len(tab):]
+				}
+				text = strings.Join(lines, "\n")
+			}
+		}
+	}
 
 	err := st.SetVars(Vars{
 		op.text:  nodes.String(text),
@@ -439,12 +630,70 @@ func (op commentNorm) Construct(st *State, n nodes.Node) (nodes.Node, error) {
 	if err != nil {
 		return nil, err
 	}
-	// FIXME: handle tab
+	return nodes.String(commentSource(string(text), string(pref), string(suff), string(tab), bool(block))), nil
+}
+
+// commentSource restores the source of a comment from its normalized parts.
+func commentSource(text, pref, suff, tab string, block bool) string {
+	if tab != "" {
+		text = strings.Replace(text, "\n", "\n"+tab, -1)
+	}
 	text = pref + text + suff
 	if !block {
-		return "//" + text, nil
+		return "//" + text
+	}
+	return "/*" + text + "*/"
+}
+
+// commentIndent returns a prefix of the comment line that consists of whitespaces and asterisks.
+func commentIndent(line string) string {
+	i := 0
+	for ; i < len(line) && (line[i] == ' ' || line[i] == '\t' || line[i] == '*'); i++ {
+	}
+	return line[:i]
+}
+
+// commonPrefix returns the longest common prefix of a and b.
+func commonPrefix(a, b string) string {
+	i := 0
+	for ; i < len(a) && i < len(b) && a[i] == b[i]; i++ {
+	}
+	return a[:i]
+}
+
+// commentGroupText stores the list of comments of a CommentGroup and computes the text of the group.
+type commentGroupText struct {
+	list, text string
+}
+
+func (commentGroupText) Kinds() nodes.Kind {
+	return nodes.KindArray
+}
+
+func (op commentGroupText) Check(st *State, n nodes.Node) (bool, error) {
+	arr, ok := n.(nodes.Array)
+	if !ok {
+		return false, nil
+	}
+	group := &ast.CommentGroup{List: make([]*ast.Comment, 0, len(arr))}
+	for _, c := range arr {
+		var com uast.Comment
+		if err := uast.NodeAs(c, &com); err != nil {
+			return false, nil
+		}
+		text := commentSource(com.Text, com.Prefix, com.Suffix, com.Tab, com.Block)
+		group.List = append(group.List, &ast.Comment{Text: text})
 	}
-	return "/*" + text + "*/", nil
+	err := st.SetVars(Vars{
+		op.list: arr,
+		op.text: nodes.String(group.Text()),
+	})
+	return err == nil, err
+}
+
+func (op commentGroupText) Construct(st *State, n nodes.Node) (nodes.Node, error) {
+	// the text can be derived from comments
+	return st.MustGetVar(op.list)
 }
 
 type fieldSplit struct {
@@ -471,9 +720,12 @@ func (op fieldSplit) Check(st *State, n nodes.Node) (bool, error) {
 			continue
 		}
 		objs := make([]nodes.Node, 0, len(names))
-		for _, name := range names {
+		for j, name := range names {
 			v := obj.CloneObject()
-			// TODO: store additional info to join nodes later
+			if j != 0 {
+				// the field and its type are duplicated, while IDs must refer to a single node
+				v = withoutIDs(obj).(nodes.Object)
+			}
 			v["Names"] = nodes.Array{name}
 			objs = append(objs, v)
 		}
@@ -487,7 +739,55 @@ func (op fieldSplit) Check(st *State, n nodes.Node) (bool, error) {
 }
 
 func (op fieldSplit) Construct(st *State, n nodes.Node) (nodes.Node, error) {
-	return st.MustGetVar(op.vr) // TODO: join nodes back on reverse
+	v, err := st.MustGetVar(op.vr)
+	if err != nil {
+		return nil, err
+	}
+	arr, ok := v.(nodes.Array)
+	if !ok {
+		return v, nil
+	}
+	// fields split by Check share the same type node, thus adjacent fields with
+	// equal types and positions are joined back
+	out := make(nodes.Array, 0, len(arr))
+	for _, f := range arr {
+		obj, ok := f.(nodes.Object)
+		if ok && len(out) != 0 {
+			if prev, ok := out[len(out)-1].(nodes.Object); ok && canJoinFields(prev, obj) {
+				prev = prev.CloneObject()
+				prev["Names"] = append(prev["Names"].(nodes.Array).CloneList(), obj["Names"].(nodes.Array)...)
+				out[len(out)-1] = prev
+				continue
+			}
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+// canJoinFields reports if two fields were produced by fieldSplit from a single field.
+func canJoinFields(a, b nodes.Object) bool {
+	an, ok1 := a["Names"].(nodes.Array)
+	bn, ok2 := b["Names"].(nodes.Array)
+	if !ok1 || !ok2 || len(an) == 0 || len(bn) != 1 {
+		return false
+	}
+	// only the first field keeps IDs (see fieldSplit.Check)
+	typ, ok := a["Type"].(nodes.Object)
+	if !ok || !nodes.Equal(withoutIDs(typ), withoutIDs(b["Type"])) {
+		return false
+	}
+	pos, ok := typ[uast.KeyPos].(nodes.Object)
+	if !ok || pos[uast.KeyStart] == nil {
+		// cannot distinguish "a, b T" from "a T, b T" without positions
+		return false
+	}
+	for _, k := range []string{"Tag", "Doc", "Comment"} {
+		if !nodes.Equal(withoutIDs(a[k]), withoutIDs(b[k])) {
+			return false
+		}
+	}
+	return true
 }
 
 // pathSplit splits the Go imports path and constructs a QualifiedIdentifier from it.
@@ -553,5 +853,215 @@ func (op pathSplit) Check(st *State, n nodes.Node) (bool, error) {
 }
 
 func (op pathSplit) Construct(st *State, n nodes.Node) (nodes.Node, error) {
-	return op.path.Construct(st, n) // TODO: join nodes back on reverse
+	nd, err := op.path.Construct(st, n)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := nd.(nodes.Object)
+	if !ok {
+		return nil, ErrUnexpectedType.New(nodes.Object{}, nd)
+	}
+	var path uast.String
+	switch uast.TypeOf(obj) {
+	case uast.TypeOf(uast.Identifier{}):
+		var id uast.Identifier
+		if err := uast.NodeAs(obj, &id); err != nil {
+			return nil, err
+		}
+		path = uast.String{GenNode: id.GenNode, Value: id.Name}
+	case uast.TypeOf(uast.QualifiedIdentifier{}):
+		var qual uast.QualifiedIdentifier
+		if err := uast.NodeAs(obj, &qual); err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(qual.Names))
+		for _, id := range qual.Names {
+			names = append(names, id.Name)
+		}
+		path = uast.String{GenNode: qual.GenNode, Value: strings.Join(names, "/")}
+	default:
+		return nil, ErrUnexpectedType.New(nodes.String(uast.TypeOf(uast.String{})), obj)
+	}
+	return uast.ToNode(path)
+}
+
+// quoteString unquotes the Go string literal. The literal is quoted back with backquotes if it is
+// known to be a raw string literal from its positions. Otherwise, it is quoted with double quotes.
+type quoteString struct {
+	vr string
+}
+
+func (quoteString) Kinds() nodes.Kind {
+	return nodes.KindString
+}
+
+func (op quoteString) Check(st *State, n nodes.Node) (bool, error) {
+	s, ok := n.(nodes.String)
+	if !ok {
+		return false, nil
+	}
+	val, err := strconv.Unquote(string(s))
+	if err != nil {
+		return false, fmt.Errorf("%v (%s)", err, s)
+	}
+	err = st.SetVar(op.vr, nodes.String(val))
+	return err == nil, err
+}
+
+func (op quoteString) Construct(st *State, n nodes.Node) (nodes.Node, error) {
+	v, err := st.MustGetVar(op.vr)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := v.(nodes.String)
+	if !ok {
+		return nil, ErrUnexpectedType.New(nodes.String(""), v)
+	}
+	val := string(s)
+	quoted := strconv.Quote(val)
+	if n := literalLen(st); n == len(val)+2 && n != len(quoted) &&
+		!strings.ContainsAny(val, "`\r") {
+		return nodes.String("`" + val + "`"), nil
+	}
+	return nodes.String(quoted), nil
+}
+
+// literalLen returns the length of the literal in the source code,
+// or -1 if it cannot be determined from the start and end positions.
+func literalLen(st *State) int {
+	var start, end uast.Position
+	s, ok1 := st.GetVar(uast.KeyStart)
+	e, ok2 := st.GetVar(uast.KeyEnd)
+	if !ok1 || !ok2 || uast.NodeAs(s, &start) != nil || uast.NodeAs(e, &end) != nil ||
+		!start.Valid() || end.Offset < start.Offset {
+		return -1
+	}
+	return int(end.Offset - start.Offset)
+}
+
+// structTag parses a struct field tag and constructs a StructTag node from it.
+type structTag struct {
+	tag Op
+}
+
+func (structTag) Kinds() nodes.Kind {
+	return nodes.KindObject
+}
+
+func (op structTag) Check(st *State, n nodes.Node) (bool, error) {
+	obj, ok := n.(nodes.Object)
+	if !ok || uast.TypeOf(obj) != uast.TypeOf(uast.String{}) {
+		return false, nil
+	}
+	var str uast.String
+	if err := uast.NodeAs(obj, &str); err != nil {
+		return false, err
+	}
+	entries := parseStructTag(str.Value)
+	start, end := str.Positions.Start(), str.Positions.End()
+	if start != nil && end != nil && start.Line == end.Line &&
+		int(end.Offset-start.Offset) == len(str.Value)+2 {
+		// there are no escape sequences in the literal, thus we can reconstruct positions;
+		// entries store offsets relative to the tag value that starts after the quote
+		for i, e := range entries {
+			ps, pe := *start, *start
+			ps.Offset += e.Positions[uast.KeyStart].Offset + 1
+			ps.Col += e.Positions[uast.KeyStart].Offset + 1
+			pe.Offset += e.Positions[uast.KeyEnd].Offset + 1
+			pe.Col += e.Positions[uast.KeyEnd].Offset + 1
+			entries[i].Positions = uast.Positions{
+				uast.KeyStart: ps,
+				uast.KeyEnd:   pe,
+			}
+		}
+	} else {
+		for i := range entries {
+			entries[i].Positions = nil
+		}
+	}
+	nd, err := uast.ToNode(semantic.StructTag{
+		GenNode: str.GenNode,
+		Value:   str.Value,
+		Format:  str.Format,
+		Entries: entries,
+	})
+	if err != nil {
+		return false, err
+	}
+	return op.tag.Check(st, nd)
+}
+
+func (op structTag) Construct(st *State, n nodes.Node) (nodes.Node, error) {
+	nd, err := op.tag.Construct(st, n)
+	if err != nil || nd == nil {
+		return nd, err
+	}
+	var tag semantic.StructTag
+	if err := uast.NodeAs(nd, &tag); err != nil {
+		return nil, err
+	}
+	return uast.ToNode(uast.String{GenNode: tag.GenNode, Value: tag.Value, Format: tag.Format})
+}
+
+// parseStructTag splits the tag into key:"value" pairs the same way reflect.StructTag.Lookup does.
+// Positions of entries are set to byte offsets relative to the beginning of the tag.
+func parseStructTag(tag string) []semantic.TagEntry {
+	var (
+		out []semantic.TagEntry
+		off int
+	)
+	for tag != "" {
+		// skip leading space
+		i := 0
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+		tag = tag[i:]
+		off += i
+		if tag == "" {
+			break
+		}
+		start := off
+		// scan to colon; a space, a quote or a control character is a syntax error
+		i = 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			break
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+		off += i + 1
+
+		// scan quoted string to find the value
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			break
+		}
+		qvalue := tag[:i+1]
+		tag = tag[i+1:]
+		off += i + 1
+
+		value, err := strconv.Unquote(qvalue)
+		if err != nil {
+			break
+		}
+		opts := strings.Split(value, ",")
+		out = append(out, semantic.TagEntry{
+			GenNode: uast.GenNode{Positions: uast.Positions{
+				uast.KeyStart: {Offset: uint32(start)},
+				uast.KeyEnd:   {Offset: uint32(off)},
+			}},
+			Key: key, Value: value,
+			Name: opts[0], Options: opts[1:],
+		})
+	}
+	return out
 }
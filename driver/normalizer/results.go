@@ -0,0 +1,116 @@
+package normalizer
+
+import (
+	"github.com/bblfsh/go-driver/driver/semantic"
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+	. "github.com/bblfsh/sdk/v3/uast/transformer"
+)
+
+const (
+	// keyNamedResults is a temporary field of FuncType that stores a list of semantic.NamedResult nodes.
+	// It is set by namedResults and is moved to the NamedResults field by the FuncType normalizer.
+	keyNamedResults = "@results"
+
+	// keyImplicitResults is a field of a bare ReturnStmt that lists the named results it returns.
+	keyImplicitResults = "ImplicitResults"
+)
+
+var _ Transformer = namedResults{}
+
+// namedResults lists named results of functions and links bare return statements to them.
+type namedResults struct{}
+
+func (namedResults) Do(root nodes.Node) (nodes.Node, error) {
+	if nn, ok := linkResults(root, nil); ok {
+		return nn, nil
+	}
+	return root, nil
+}
+
+// linkResults finds all functions with named results in the tree and bare returns in their bodies.
+// The results argument is a list of named results of the innermost function, if any.
+func linkResults(n nodes.Node, results nodes.Array) (nodes.Node, bool) {
+	switch n := n.(type) {
+	case nodes.Array:
+		var out nodes.Array
+		for i, v := range n {
+			if nv, ok := linkResults(v, results); ok {
+				if out == nil {
+					out = n.CloneList()
+				}
+				out[i] = nv
+			}
+		}
+		if out == nil {
+			return n, false
+		}
+		return out, true
+	case nodes.Object:
+		var out nodes.Object
+		switch uast.TypeOf(n) {
+		case "FuncDecl", "FuncLit":
+			// a nested function literal has its own results
+			results = nil
+			if typ, ok := n["Type"].(nodes.Object); ok {
+				results = namedResultsOf(typ)
+				if results != nil {
+					typ = typ.CloneObject()
+					typ[keyNamedResults] = results
+					out = n.CloneObject()
+					out["Type"] = typ
+				}
+			}
+		case "ReturnStmt":
+			if res, _ := n["Results"].(nodes.Array); len(res) == 0 && results != nil {
+				out = n.CloneObject()
+				out[keyImplicitResults] = results.CloneList()
+			}
+		}
+		for k, v := range n {
+			if k == "Type" && out != nil {
+				// already processed; function types cannot contain bare returns
+				continue
+			}
+			if nv, ok := linkResults(v, results); ok {
+				if out == nil {
+					out = n.CloneObject()
+				}
+				out[k] = nv
+			}
+		}
+		if out == nil {
+			return n, false
+		}
+		return out, true
+	}
+	return n, false
+}
+
+// namedResultsOf returns a list of semantic.NamedResult nodes for a FuncType, or nil if results are not named.
+// Results are referenced by names and indexes; the identifiers stay in the list of results.
+func namedResultsOf(typ nodes.Object) nodes.Array {
+	list, _ := typ["Results"].(nodes.Object)
+	fields, _ := list["List"].(nodes.Array)
+	var (
+		out   nodes.Array
+		index = 0
+	)
+	for _, f := range fields {
+		field, _ := f.(nodes.Object)
+		names, _ := field["Names"].(nodes.Array)
+		if len(names) == 0 {
+			// results are either all named or all unnamed
+			return nil
+		}
+		for _, name := range names {
+			out = append(out, nodes.Object{
+				uast.KeyType: nodes.String(uast.TypeOf(semantic.NamedResult{})),
+				"Name":       nodes.String(identName(name)),
+				"Index":      nodes.Int(index),
+			})
+			index++
+		}
+	}
+	return out
+}
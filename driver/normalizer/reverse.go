@@ -0,0 +1,274 @@
+package normalizer
+
+import (
+	"strings"
+
+	"github.com/bblfsh/go-driver/driver/golang"
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+	. "github.com/bblfsh/sdk/v3/uast/transformer"
+)
+
+// namespace is a prefix of types of native nodes in semantic UAST.
+const namespace = "go:"
+
+// Unannotate is a list of transformations that convert an annotated UAST back to the native AST:
+// it is the inverse of Native. The result can be converted to Go code with golang.NodeToAST.
+var Unannotate = Transformers([][]Transformer{
+	{unannotate{}},
+}...)
+
+// Denormalize is a list of transformations that convert a semantic UAST back to the native AST:
+// it is the inverse of Normalize and Native. The result can be converted to Go code with golang.NodeToAST.
+var Denormalize = Transformers([][]Transformer{
+	// Annotations are removed from the whole tree first, since native nodes are annotated
+	// after normalization.
+	{nativeTypes{}, unannotate{}},
+	// Deprecation markers are derived from doc comments.
+	{dropFields{keyDeprecated}},
+	{reverseMappings(withIDs(Normalizers))},
+	// Temporary fields set by the transformations that run before normalizers.
+	{dropFields{keyCompositeKind, keyDocBlocks, keyReceiver, keyMethodSets, keyNamedResults, keyImplicitResults}},
+}...)
+
+// ToNative converts an annotated or a semantic UAST of a Go file to the native AST.
+// Native trees are returned as-is.
+func ToNative(n nodes.Node) (nodes.Node, error) {
+	list := Unannotate
+	if obj, ok := n.(nodes.Object); ok && strings.HasPrefix(uast.TypeOf(obj), namespace) {
+		list = Denormalize
+	}
+	var err error
+	for _, t := range list {
+		n, err = t.Do(n)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// ToCode converts a native, annotated or semantic UAST of a Go file to formatted Go code.
+// Positions are ignored, thus the layout of the code is determined by the formatter (see golang.Print).
+func ToCode(n nodes.Node) (string, error) {
+	n, err := ToNative(n)
+	if err != nil {
+		return "", err
+	}
+	return golang.Print(n)
+}
+
+var (
+	_ Transformer = nativeTypes{}
+	_ Transformer = unannotate{}
+	_ Transformer = dropFields{}
+	_ Transformer = reverseMappings{}
+)
+
+// reverseMappings constructs the source trees of mappings from their destination trees.
+//
+// Mappings are applied bottom-up, thus a mapping of the node expects its children to be already
+// converted. Reversed mappings are applied top-down for the same reason: a parent node is restored
+// first, while its children are still in the form expected by the mapping. All mappings that match
+// the node are applied to it in the reverse order.
+type reverseMappings []Mapping
+
+func (m reverseMappings) Do(root nodes.Node) (nodes.Node, error) {
+	st := NewState()
+	var apply func(n nodes.Node) (nodes.Node, error)
+	apply = func(n nodes.Node) (nodes.Node, error) {
+		if obj, ok := n.(nodes.Object); ok {
+			n = withStart(obj)
+		}
+		for i := len(m) - 1; i >= 0; i-- {
+			src, dst := m[i].Mapping()
+			st.Reset()
+			if ok, err := dst.Check(st, n); err != nil {
+				return nil, err
+			} else if !ok {
+				continue
+			}
+			nn, err := src.Construct(st, nil)
+			if err != nil {
+				return nil, err
+			}
+			n = nn
+		}
+		switch n := n.(type) {
+		case nodes.Object:
+			out := make(nodes.Object, len(n))
+			for k, v := range n {
+				v, err := apply(v)
+				if err != nil {
+					return nil, err
+				}
+				out[k] = v
+			}
+			return out, nil
+		case nodes.Array:
+			out := make(nodes.Array, 0, len(n))
+			for _, v := range n {
+				v, err := apply(v)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, v)
+			}
+			return out, nil
+		}
+		return n, nil
+	}
+	return apply(root)
+}
+
+// withStart sets an unknown start position for semantic nodes without one. Mappings of semantic
+// nodes require the start position to restore positions of native nodes, but some of the
+// semantic nodes are constructed by normalizers without positions.
+func withStart(obj nodes.Object) nodes.Node {
+	typ := uast.TypeOf(obj)
+	if !strings.Contains(typ, ":") || typ == uast.TypePositions || typ == uast.TypePosition {
+		return obj
+	}
+	pos, _ := obj[uast.KeyPos].(nodes.Object)
+	if pos[uast.KeyStart] != nil {
+		return obj
+	}
+	start, err := uast.ToNode(uast.Position{})
+	if err != nil {
+		panic(err)
+	}
+	obj = obj.CloneObject()
+	if pos == nil {
+		pos = nodes.Object{uast.KeyType: nodes.String(uast.TypePositions)}
+	} else {
+		pos = pos.CloneObject()
+	}
+	pos[uast.KeyStart] = start
+	obj[uast.KeyPos] = pos
+	return obj
+}
+
+// nativeTypes removes the namespace from types of native nodes of the semantic UAST.
+type nativeTypes struct{}
+
+func (nativeTypes) Do(root nodes.Node) (nodes.Node, error) {
+	nn, _ := nodes.Apply(root, func(n nodes.Node) (nodes.Node, bool) {
+		obj, ok := n.(nodes.Object)
+		if !ok {
+			return n, false
+		}
+		typ := uast.TypeOf(obj)
+		if !strings.HasPrefix(typ, namespace) {
+			return n, false
+		}
+		obj = obj.CloneObject()
+		obj[uast.KeyType] = nodes.String(strings.TrimPrefix(typ, namespace))
+		return obj, true
+	})
+	return nn, nil
+}
+
+// unannotate removes roles from all nodes and restores fields of native nodes that were
+// renamed or converted by Annotations.
+type unannotate struct{}
+
+func (unannotate) Do(root nodes.Node) (nodes.Node, error) {
+	nn, _ := nodes.Apply(root, func(n nodes.Node) (nodes.Node, bool) {
+		obj, ok := n.(nodes.Object)
+		if !ok {
+			return n, false
+		}
+		_, roles := obj[uast.KeyRoles]
+		rename := ""
+		switch uast.TypeOf(obj) {
+		case "Comment":
+			rename = "Text"
+		case "Ident":
+			rename = "Name"
+		case "BasicLit":
+			rename = "Value"
+		}
+		if !roles && rename == "" && !hasOperator(obj) {
+			return n, false
+		}
+		obj = obj.CloneObject()
+		delete(obj, uast.KeyRoles)
+		if tok, ok := obj[uast.KeyToken]; ok && rename != "" {
+			delete(obj, uast.KeyToken)
+			if rename == "Text" {
+				tok = nodes.String(commentText(string(tok.(nodes.String)), obj))
+			}
+			obj[rename] = tok
+		}
+		switch uast.TypeOf(obj) {
+		case "BinaryExpr", "UnaryExpr":
+			obj["Op"] = operatorToken(obj["Op"])
+		case "IncDecStmt", "AssignStmt":
+			obj["Tok"] = operatorToken(obj["Op"])
+			delete(obj, "Op")
+		}
+		return obj, true
+	})
+	return nn, nil
+}
+
+// hasOperator reports if the native node has an operator converted to uast:Operator by Annotations.
+func hasOperator(obj nodes.Object) bool {
+	op, ok := obj["Op"].(nodes.Object)
+	return ok && uast.TypeOf(op) == uast.TypeOperator
+}
+
+// operatorToken returns the token of the operator node.
+func operatorToken(n nodes.Node) nodes.Node {
+	op, ok := n.(nodes.Object)
+	if !ok || uast.TypeOf(op) != uast.TypeOperator {
+		return n
+	}
+	return op[uast.KeyToken]
+}
+
+// commentText restores the text of a comment that was uncommented by Annotations. The kind of the
+// comment is determined from its positions, if possible.
+func commentText(s string, obj nodes.Object) string {
+	block := strings.Contains(s, "\n")
+	pos := uast.PositionsOf(obj)
+	if start, end := pos.Start(), pos.End(); start != nil && start.Valid() && end != nil && end.Offset >= start.Offset {
+		switch int(end.Offset - start.Offset) {
+		case len(s) + 2:
+			block = false
+		case len(s) + 4:
+			block = true
+		}
+	}
+	if block {
+		return "/*" + s + "*/"
+	}
+	return "//" + s
+}
+
+// dropFields removes fields with given names from all objects of the tree.
+type dropFields []string
+
+func (f dropFields) Do(root nodes.Node) (nodes.Node, error) {
+	nn, _ := nodes.Apply(root, func(n nodes.Node) (nodes.Node, bool) {
+		obj, ok := n.(nodes.Object)
+		if !ok {
+			return n, false
+		}
+		var out nodes.Object
+		for _, k := range f {
+			if _, ok := obj[k]; !ok {
+				continue
+			}
+			if out == nil {
+				out = obj.CloneObject()
+			}
+			delete(out, k)
+		}
+		if out == nil {
+			return n, false
+		}
+		return out, true
+	})
+	return nn, nil
+}
@@ -0,0 +1,134 @@
+package normalizer
+
+import (
+	"go/ast"
+
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+	"github.com/bblfsh/sdk/v3/uast/role"
+	. "github.com/bblfsh/sdk/v3/uast/transformer"
+)
+
+var (
+	// exportedRoles are set on identifiers of exported declarations.
+	exportedRoles = []role.Role{role.Visibility, role.World}
+	// unexportedRoles are set on identifiers of unexported declarations,
+	// which are only visible in the same package.
+	unexportedRoles = []role.Role{role.Visibility, role.Package}
+)
+
+// visibilityRoles marks names of package-level declarations (functions, methods, types, variables
+// and constants), struct fields and interface methods as exported or unexported.
+//
+// It works both on annotated and semantic trees. Local declarations are not marked, since Go has
+// no notion of visibility for them.
+func visibilityRoles() Transformer {
+	return TransformObjFunc(func(obj nodes.Object) (nodes.Object, bool, error) {
+		switch uast.TypeOf(obj) {
+		case "File":
+			return setArrayVisibility(obj, "Decls", declVisibility)
+		case "StructType":
+			return setVisibility(obj, "Fields", fieldsVisibility)
+		case "InterfaceType":
+			return setVisibility(obj, "Methods", fieldsVisibility)
+		}
+		return obj, false, nil
+	})
+}
+
+// declVisibility marks names of a top-level declaration.
+func declVisibility(decl nodes.Object) (nodes.Object, bool, error) {
+	switch uast.TypeOf(decl) {
+	case "FuncDecl":
+		return setVisibility(decl, "Name", identVisibility)
+	case uast.TypeOf(uast.FunctionGroup{}):
+		return setArrayVisibility(decl, "Nodes", func(n nodes.Object) (nodes.Object, bool, error) {
+			if uast.TypeOf(n) != uast.TypeOf(uast.Alias{}) {
+				return n, false, nil
+			}
+			return setVisibility(n, "Name", identVisibility)
+		})
+	case "GenDecl":
+		return setArrayVisibility(decl, "Specs", func(spec nodes.Object) (nodes.Object, bool, error) {
+			switch uast.TypeOf(spec) {
+			case "TypeSpec":
+				return setVisibility(spec, "Name", identVisibility)
+			case "ValueSpec":
+				return setArrayVisibility(spec, "Names", identVisibility)
+			}
+			return spec, false, nil
+		})
+	}
+	return decl, false, nil
+}
+
+// fieldsVisibility marks names of fields of a FieldList.
+func fieldsVisibility(list nodes.Object) (nodes.Object, bool, error) {
+	return setArrayVisibility(list, "List", func(field nodes.Object) (nodes.Object, bool, error) {
+		return setArrayVisibility(field, "Names", identVisibility)
+	})
+}
+
+// identVisibility adds visibility roles to an identifier, either native or semantic one.
+func identVisibility(id nodes.Object) (nodes.Object, bool, error) {
+	var name nodes.String
+	switch uast.TypeOf(id) {
+	case "Ident":
+		// annotations store the name as a token
+		name, _ = id[uast.KeyToken].(nodes.String)
+	case uast.TypeOf(uast.Identifier{}):
+		name, _ = id["Name"].(nodes.String)
+	default:
+		return id, false, nil
+	}
+	roles := unexportedRoles
+	if ast.IsExported(string(name)) {
+		roles = exportedRoles
+	}
+	id = id.CloneObject()
+	id[uast.KeyRoles] = uast.RoleList(append(uast.RolesOf(id), roles...)...)
+	return id, true, nil
+}
+
+// setVisibility applies fnc to an object stored in a given field of obj.
+func setVisibility(obj nodes.Object, field string, fnc TransformObjFunc) (nodes.Object, bool, error) {
+	sub, ok := obj[field].(nodes.Object)
+	if !ok {
+		return obj, false, nil
+	}
+	sub, ok, err := fnc(sub)
+	if err != nil || !ok {
+		return obj, false, err
+	}
+	obj = obj.CloneObject()
+	obj[field] = sub
+	return obj, true, nil
+}
+
+// setArrayVisibility applies fnc to each object in an array stored in a given field of obj.
+func setArrayVisibility(obj nodes.Object, field string, fnc TransformObjFunc) (nodes.Object, bool, error) {
+	arr, _ := obj[field].(nodes.Array)
+	var out nodes.Array
+	for i, v := range arr {
+		sub, ok := v.(nodes.Object)
+		if !ok {
+			continue
+		}
+		sub, ok, err := fnc(sub)
+		if err != nil {
+			return obj, false, err
+		} else if !ok {
+			continue
+		}
+		if out == nil {
+			out = arr.CloneList()
+		}
+		out[i] = sub
+	}
+	if out == nil {
+		return obj, false, nil
+	}
+	obj = obj.CloneObject()
+	obj[field] = out
+	return obj, true, nil
+}
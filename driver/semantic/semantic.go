@@ -0,0 +1,228 @@
+// Package semantic defines Go-specific semantic UAST nodes that are not (yet) a part of the SDK.
+package semantic
+
+import (
+	"github.com/bblfsh/sdk/v3/uast"
+)
+
+// NS is a namespace for Go-specific semantic nodes.
+const NS = "go-sem"
+
+func init() {
+	uast.RegisterPackage(NS,
+		CollectionLiteral{},
+		RecordLiteral{},
+		UnknownLiteral{},
+		KeyValue{},
+		StructTag{},
+		TagEntry{},
+		CommentGroup{},
+		DocHeading{},
+		DocParagraph{},
+		DocCode{},
+		DocList{},
+		DocListItem{},
+		DocLink{},
+		DocSymbolLink{},
+		Deprecation{},
+		Receiver{},
+		MethodSets{},
+		NamedResult{},
+	)
+}
+
+// Kinds of collection literals.
+const (
+	KindSlice = "slice"
+	KindArray = "array"
+	KindMap   = "map"
+)
+
+// CollectionLiteral is a composite literal of a slice, an array or a map type.
+type CollectionLiteral struct {
+	uast.GenNode
+	// Kind is one of KindSlice, KindArray or KindMap.
+	Kind string `json:"Kind"`
+	// Type is the type of the literal. It is nil if the type was elided,
+	// for example, for inner literals of [][]int{{1}}.
+	Type uast.Any `json:"Type"`
+	// Elements is a list of elements of the collection. Keyed elements are
+	// represented by KeyValue nodes, positional elements are stored as-is.
+	Elements []uast.Any `json:"Elements"`
+}
+
+// RecordLiteral is a composite literal of a struct type.
+type RecordLiteral struct {
+	uast.GenNode
+	// Type is the type of the literal. It is nil if the type was elided,
+	// for example, for inner literals of []Point{{1, 2}}.
+	Type uast.Any `json:"Type"`
+	// Fields is a list of field values. Keyed fields are represented by
+	// KeyValue nodes, positional fields are stored as-is.
+	Fields []uast.Any `json:"Fields"`
+}
+
+// UnknownLiteral is a composite literal of a type that cannot be resolved without a type information,
+// for example, a positional literal of an imported type. It may be either a struct or a collection literal.
+type UnknownLiteral struct {
+	uast.GenNode
+	// Type is the type of the literal. It is nil if the type was elided.
+	Type uast.Any `json:"Type"`
+	// Elements is a list of elements of the literal. Keyed elements are represented by KeyValue
+	// nodes, positional elements are stored as-is.
+	Elements []uast.Any `json:"Elements"`
+}
+
+// KeyValue is a keyed element of a composite literal.
+type KeyValue struct {
+	uast.GenNode
+	Key   uast.Any `json:"Key"`
+	Value uast.Any `json:"Value"`
+}
+
+// StructTag is a tag of a struct field, parsed according to reflect.StructTag conventions.
+type StructTag struct {
+	uast.GenNode
+	// Value is the raw value of the tag.
+	Value string `json:"Value" uast:",content"`
+	// Format is the format of the string literal of the tag, as in uast.String.
+	Format string `json:"Format"`
+	// Entries is a list of key:"value" pairs of the tag. Parsing stops at the first malformed pair,
+	// as reflect.StructTag.Lookup does.
+	Entries []TagEntry `json:"Entries"`
+}
+
+// TagEntry is a single key:"value" pair of a struct field tag, like json:"name,omitempty".
+type TagEntry struct {
+	uast.GenNode
+	Key string `json:"Key"`
+	// Value is the unquoted value of the entry.
+	Value string `json:"Value"`
+	// Name is a part of the value before the first comma.
+	Name string `json:"Name"`
+	// Options is a list of comma-separated options that follow the name.
+	Options []string `json:"Options"`
+}
+
+// CommentGroup is a sequence of comments with no other tokens and no empty lines between them.
+// Doc and line comments of declarations are attached as CommentGroup nodes to Doc and Comment
+// fields of corresponding semantic nodes.
+type CommentGroup struct {
+	uast.GenNode
+	// Text is the text of the comments with comment markers, directives and leading and trailing
+	// empty lines removed, as returned by ast.CommentGroup.Text.
+	Text     string         `json:"Text" uast:",content"`
+	Comments []uast.Comment `json:"Comments"`
+	// Blocks is a content of a doc comment parsed according to the Go doc comment syntax.
+	// Each block is one of DocHeading, DocParagraph, DocCode or DocList.
+	// It is only set for doc comments of declarations and files, and is nil for other comments.
+	Blocks []uast.Any `json:"Blocks"`
+}
+
+// DocHeading is a section heading of a doc comment, either "# Heading" or an old-style implicit one.
+type DocHeading struct {
+	uast.GenNode
+	Text string `json:"Text" uast:",content"`
+}
+
+// DocParagraph is a paragraph of a doc comment.
+type DocParagraph struct {
+	uast.GenNode
+	// Text is the text of the paragraph, as written in the comment.
+	Text string `json:"Text" uast:",content"`
+	// Content is the text split into plain strings, DocLink and DocSymbolLink nodes.
+	Content []uast.Any `json:"Content"`
+	// Deprecated is set for paragraphs starting with "Deprecated: ".
+	Deprecated bool `json:"Deprecated"`
+}
+
+// DocCode is a preformatted code block of a doc comment.
+type DocCode struct {
+	uast.GenNode
+	// Text is the unindented text of the block, ending with a newline.
+	Text string `json:"Text" uast:",content"`
+}
+
+// DocList is a bullet or a numbered list of a doc comment.
+type DocList struct {
+	uast.GenNode
+	Items []DocListItem `json:"Items"`
+}
+
+// DocListItem is a single item of a DocList.
+type DocListItem struct {
+	uast.GenNode
+	// Number is a decimal number of the item in a numbered list, or an empty string for bullet lists.
+	Number string `json:"Number"`
+	// Content is a list of DocParagraph nodes of the item.
+	Content []uast.Any `json:"Content"`
+}
+
+// DocLink is a link to an URL, either written as is or defined with a "[Text]: URL" line.
+type DocLink struct {
+	uast.GenNode
+	Text string `json:"Text" uast:",content"`
+	URL  string `json:"URL"`
+	// Auto is set for URLs that were linked automatically.
+	Auto bool `json:"Auto"`
+}
+
+// DocSymbolLink is a link to a Go package or symbol, like [io.Reader] or [Buffer.Len].
+//
+// Symbols of the current package are only recognized if they are declared in the same file,
+// and packages are recognized by imports of the file and by names of standard packages.
+type DocSymbolLink struct {
+	uast.GenNode
+	Text string `json:"Text" uast:",content"`
+	// ImportPath is the import path of the package, or an empty string for the current package.
+	ImportPath string `json:"ImportPath"`
+	// Recv is the receiver type of a method, without a pointer.
+	Recv string `json:"Recv"`
+	// Name is the name of a const, func, type, var or method. It is empty for links to packages.
+	Name string `json:"Name"`
+}
+
+// Deprecation marks a declaration that has a "Deprecated: " paragraph in its doc comment.
+// It is stored in the Deprecated field of the declaration node.
+type Deprecation struct {
+	uast.GenNode
+	// Message is the text of the paragraph without the "Deprecated: " prefix.
+	Message string `json:"Message" uast:",content"`
+}
+
+// Receiver describes a receiver of a method. It is stored in the Receiver field of the function group.
+type Receiver struct {
+	uast.GenNode
+	// Type is the name of the receiver base type, without a pointer and type parameters.
+	Type string `json:"Type"`
+	// Pointer is set for receivers of a pointer type, like *T.
+	Pointer bool `json:"Pointer"`
+	// Name is the name of the receiver. It is empty if the receiver is unnamed.
+	Name string `json:"Name"`
+	// Blank is set if the receiver is unnamed or is named "_".
+	Blank bool `json:"Blank"`
+}
+
+// MethodSets lists the methods declared for a type. It is stored in the MethodSets field
+// of the type declaration. Methods are referenced by names of their function groups.
+type MethodSets struct {
+	uast.GenNode
+	// Value is the method set of the type T: names of methods with value receivers.
+	Value []string `json:"Value"`
+	// Pointer is the method set of the type *T: names of methods with both value and pointer receivers.
+	Pointer []string `json:"Pointer"`
+}
+
+// NamedResult is a named result parameter of a function. Named results are local variables
+// initialized to zero values, and a bare return statement returns their current values.
+//
+// Named results are listed in the NamedResults field of the function type, and in the
+// ImplicitResults field of bare return statements of the function.
+type NamedResult struct {
+	uast.GenNode
+	// Name is the name of the result. The identifier that declares it stays in the list of results
+	// of the function type.
+	Name string `json:"Name"`
+	// Index is the position of the result in the list of results of the function.
+	Index int `json:"Index"`
+}
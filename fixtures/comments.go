@@ -1,6 +1,9 @@
 // Package fixtures is a collection of Go code samples.
 package fixtures
 
+// fmt is imported for a sample
+import "fmt" // import comment
+
 // detached comment
 
 /* detached comment 2 */
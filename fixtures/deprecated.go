@@ -0,0 +1,45 @@
+package fixtures
+
+// OldFunc does nothing.
+//
+// Deprecated: use NewFunc instead.
+func OldFunc() {}
+
+// NewFunc does nothing.
+func NewFunc() {}
+
+// OldType is a sample type.
+//
+// Deprecated: OldType is replaced by
+// [NewType] since v2.
+type OldType struct {
+	// Name is a sample field.
+	//
+	// Deprecated: use ID.
+	Name string
+	ID   int
+}
+
+// NewType is a sample type.
+type NewType struct{}
+
+// Run is a sample method.
+//
+// Deprecated: use [NewType] methods.
+func (t *OldType) Run() {}
+
+// Deprecated: use MaxSize.
+const Limit = 10
+
+const (
+	// MaxSize is a limit.
+	MaxSize = 10
+
+	// MinSize is a limit.
+	//
+	// Deprecated: always zero.
+	MinSize = 0
+)
+
+// Deprecated: use NewType values.
+var Default OldType
@@ -0,0 +1,53 @@
+// Package fixtures shows the syntax of Go doc comments.
+//
+// # Headings
+//
+// A heading is a single line starting with a number sign.
+//
+// Old Style Heading
+//
+// An old-style heading is a capitalized line without punctuation.
+//
+// # Code blocks
+//
+// A code block is indented:
+//
+//	if err != nil {
+//		return err
+//	}
+//
+// # Lists
+//
+// Bullet lists:
+//   - first item
+//     continues here
+//   - second item
+//
+// Numbered lists:
+//  1. one
+//  2. two
+//
+// # Links
+//
+// See the [Go home page] or https://go.dev/doc/comment for details.
+// Doc links: [Reader], [Reader.Read], [*Reader], [strings.Builder], [fmt], [io.Reader], [golang.org/x/tools/go/ast/astutil].
+// Not links: map[Reader]int, [unknown], a[i].
+//
+// [Go home page]: https://go.dev
+package fixtures
+
+import (
+	str "strings"
+)
+
+// Reader reads from a string.
+//
+// Deprecated: use [str.Reader] instead.
+type Reader struct {
+	s string
+}
+
+// Read implements ``io.Reader''.
+func (r *Reader) Read(p []byte) (int, error) {
+	return copy(p, r.s), nil
+}
@@ -1,5 +1,7 @@
 package fixtures
 
+import "image"
+
 var (
 	_ = nil
 
@@ -23,4 +25,6 @@ line`
 	_ = [1]byte{0}
 	_ = map[string]string{"foo": "bar"}
 	_ = struct{ name string }{"foo"}
+	_ = image.Point{1, 2}
+	_ = image.Point{X: 1}
 )
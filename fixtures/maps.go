@@ -22,4 +22,10 @@ func maps() {
 	for k, v := range m {
 		_, _ = k, v
 	}
+
+	byName := map[string]pair{"a": {"b", "c"}}
+	byPair := map[pair]int{{k: "d", v: "e"}: 1}
+	_, _ = byName, byPair
 }
+
+type pair struct{ k, v string }
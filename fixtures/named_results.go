@@ -0,0 +1,25 @@
+package fixtures
+
+func divmod(a, b int) (q, r int, err error) {
+	if b == 0 {
+		return 0, 0, nil
+	}
+	q, r = a/b, a%b
+	return
+}
+
+func nested() (n int) {
+	f := func() (s string) {
+		s = "x"
+		return
+	}
+	n = len(f())
+	return
+}
+
+func unnamed() int {
+	func() {
+		return
+	}()
+	return 0
+}
@@ -0,0 +1,15 @@
+package fixtures
+
+type Counter struct {
+	n int
+}
+
+func (c Counter) Value() int { return c.n }
+
+func (c *Counter) Inc() { c.n++ }
+
+func (_ *Counter) Reset() {}
+
+func (Counter) String() string { return "counter" }
+
+func (p *(Counter)) Add(v int) { p.n += v }
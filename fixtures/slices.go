@@ -26,4 +26,11 @@ func slices() {
 	for i, v := range b {
 		_, _ = i, v
 	}
+
+	pts := []vec{{1, 2}, {x: 3}}
+	ptrs := []*vec{{4, 5}}
+	grid := [2][]vec{{{6, 7}}}
+	_, _, _ = pts, ptrs, grid
 }
+
+type vec struct{ x, y int }
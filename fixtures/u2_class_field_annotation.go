@@ -2,4 +2,5 @@ package fixtures
 
 type Testcls1 struct {
 	Bar string `json:"bar"`
+	Baz int    `json:"baz,omitempty,string" db:"baz"`
 }